@@ -0,0 +1,8 @@
+package orderv1
+
+import _ "embed"
+
+// AvroSchema is the reader schema for Avro encoded orders.
+//
+//go:embed order.avsc
+var AvroSchema string
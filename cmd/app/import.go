@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"order-service/internal/adapter/cache"
+	"order-service/internal/adapter/db"
+	"order-service/internal/broker"
+	"order-service/internal/codec"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+	"os"
+	"os/signal"
+	"path/filepath"
+)
+
+// importOrders loads orders from an NDJSON or CSV dump as an import job.
+//
+//	app import -file orders.ndjson [-dry-run]
+//	app import -resume 12
+//
+// CSV files use the columns of /orders/export. Orders are validated like
+// strict Kafka messages; rejected rows are listed at
+// /admin/imports/{id}/errors. A job interrupted with Ctrl-C fails and goes
+// on with -resume, workers of the service leave it alone.
+func importOrders(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "NDJSON or CSV file with orders")
+	format := fs.String("format", "", "csv or ndjson, by the file extension when empty")
+	dryRun := fs.Bool("dry-run", false, "only validate the orders")
+	batch := fs.Int("batch", usecase.DefaultImportBatch, "orders per insert")
+	resume := fs.Int64("resume", 0, "id of an interrupted or failed job to go on with")
+	_ = fs.Parse(args)
+
+	if (*file == "") == (*resume == 0) {
+		log.Fatal("either -file or -resume is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pgDsn := os.Getenv("PG_DSN")
+	if err := db.RunMigrations(pgDsn); err != nil {
+		log.Fatalf("failed to run migrations: %v", err)
+	}
+	repo, err := db.NewPgRepo(pgDsn)
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+	orders := usecase.NewOrederUC(repo, cache.NewMemCache(0), broker.New(0, 0))
+	uc := usecase.NewImportUC(repo, orders, codec.NewJSONDecoder(codec.DefaultUpcasters(), true), *batch, os.Getenv("IMPORT_DIR"))
+
+	var job *domain.ImportJob
+	if *resume != 0 {
+		if job, err = uc.Resume(ctx, *resume); job == nil && err == nil {
+			log.Fatalf("job %d is unknown, done or running elsewhere", *resume)
+		}
+	} else {
+		// the path is kept in the job, workers of the service take it over
+		// once the lease runs out should this process die
+		path, _ := filepath.Abs(*file)
+		job = &domain.ImportJob{Source: path, Format: *format, DryRun: *dryRun}
+		err = uc.Start(ctx, job)
+	}
+	if errors.Is(err, context.Canceled) {
+		log.Fatalf("job %d interrupted after row %d, go on with -resume %d", job.ID, job.RowsDone, job.ID)
+	}
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+	log.Printf("job %d %s: %d rows, %d orders ok, %d already stored, %d rejected", job.ID, job.Status, job.RowsDone, job.OrdersOK, job.OrdersSkipped, job.OrdersBad)
+}
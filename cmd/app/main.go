@@ -4,15 +4,21 @@ import (
 	"context"
 	"github.com/joho/godotenv"
 	"log"
+	"order-service/cmd/grpc"
 	"order-service/cmd/http"
 	"order-service/cmd/kafkaC"
 	"order-service/internal/adapter/cache"
 	"order-service/internal/adapter/db"
+	"order-service/internal/adapter/relay"
+	"order-service/internal/adapter/webhook"
+	"order-service/internal/broker"
+	"order-service/internal/codec"
+	"order-service/internal/kafkaconn"
 	"order-service/internal/usecase"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
+	"time"
 )
 
 func main() {
@@ -20,14 +26,107 @@ func main() {
 		log.Println("⚠️  .env file not found or failed to load, fallback to OS env")
 	}
 
-	brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
-	topic := os.Getenv("KAFKA_TOPIC")
-	groupID := os.Getenv("KAFKA_GROUP_ID")
+	cmd := "serve"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+	switch cmd {
+	case "serve":
+		serve()
+	case "replay":
+		replay(os.Args[2:])
+	case "seed":
+		seed(os.Args[2:])
+	case "rates":
+		rates(os.Args[2:])
+	case "import":
+		importOrders(os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q, want serve, replay, seed, rates or import", cmd)
+	}
+}
+
+func newDecoders() *codec.Decoders {
+	var registry *codec.RegistryClient
+	if url := os.Getenv("SCHEMA_REGISTRY_URL"); url != "" {
+		registry = codec.NewRegistryClient(url, os.Getenv("SCHEMA_REGISTRY_USER"), os.Getenv("SCHEMA_REGISTRY_PASSWORD"))
+	}
+	decoders, err := codec.NewDecoders(os.Getenv("KAFKA_MESSAGE_FORMAT"), registry, envBool("KAFKA_JSON_STRICT", false))
+	if err != nil {
+		log.Fatalf("decoders: %v", err)
+	}
+	return decoders
+}
+
+// baseCurrency is the currency exchange rates are quoted in and reports
+// aggregate in.
+func baseCurrency() string {
+	if c := os.Getenv("BASE_CURRENCY"); c != "" {
+		return c
+	}
+	return "RUB"
+}
+
+func newKafkaConn() *kafkaconn.Conn {
+	conn, err := kafkaconn.FromEnv().Connect()
+	if err != nil {
+		log.Fatalf("kafka: %v", err)
+	}
+	return conn
+}
+
+func serve() {
+	conn := newKafkaConn()
+	decoders := newDecoders()
+	consumerCfg := kafkaC.Config{
+		Conn:           conn,
+		GroupID:        os.Getenv("KAFKA_GROUP_ID"),
+		CommitInterval: envDuration("KAFKA_COMMIT_INTERVAL", time.Second),
+		CommitBatch:    envInt("KAFKA_COMMIT_BATCH", 100),
+		ParkingTopic:   os.Getenv("KAFKA_PARKING_TOPIC"),
+	}
 	pgDsn := os.Getenv("PG_DSN")
 	httpAddr := os.Getenv("HTTP_ADDR")
 	if httpAddr == "" {
 		httpAddr = ":8081"
 	}
+	grpcAddr := os.Getenv("GRPC_ADDR")
+	if grpcAddr == "" {
+		grpcAddr = ":9090"
+	}
+	relayCfg := relay.Config{
+		Conn:            conn,
+		Topic:           os.Getenv("OUTBOX_TOPIC"),
+		PollInterval:    envDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+		BatchSize:       envInt("OUTBOX_BATCH_SIZE", 100),
+		Retention:       envDuration("OUTBOX_RETENTION", 72*time.Hour),
+		CleanupInterval: envDuration("OUTBOX_CLEANUP_INTERVAL", time.Hour),
+	}
+	if relayCfg.Topic == "" {
+		relayCfg.Topic = "orders-events"
+	}
+	httpCfg := http.Config{
+		Addr:              httpAddr,
+		ReadHeaderTimeout: envDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", 15*time.Second),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		MaxBodyBytes:      int64(envInt("HTTP_MAX_BODY_BYTES", 1<<20)),
+		RateLimit:         envFloat("HTTP_RATE_LIMIT", 20),
+		RateBurst:         envInt("HTTP_RATE_BURST", 40),
+		AdminToken:        os.Getenv("ADMIN_TOKEN"),
+	}
+	webhookCfg := webhook.Config{
+		PollInterval:     envDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		BatchSize:        envInt("WEBHOOK_BATCH_SIZE", 50),
+		Concurrency:      envInt("WEBHOOK_CONCURRENCY", 4),
+		Timeout:          envDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+		MaxAttempts:      envInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		BaseBackoff:      envDuration("WEBHOOK_BASE_BACKOFF", 5*time.Second),
+		MaxBackoff:       envDuration("WEBHOOK_MAX_BACKOFF", time.Hour),
+		BreakerThreshold: envInt("WEBHOOK_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  envDuration("WEBHOOK_BREAKER_COOLDOWN", time.Minute),
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
@@ -43,7 +142,37 @@ func main() {
 		log.Fatalf("postgres: %v", err)
 	}
 	mem := cache.NewMemCache(100)
-	uc := usecase.NewOrederUC(repo, mem)
+	events := broker.New(envInt("STREAM_REPLAY_SIZE", 256), envInt("STREAM_CLIENT_BUFFER", 64))
+	httpCfg.Events = events
+	uc := usecase.NewOrederUC(repo, mem, events)
+	httpCfg.Webhooks = usecase.NewWebhookUC(repo)
+	httpCfg.Rates = usecase.NewRatesUC(repo, baseCurrency())
+	analytics := usecase.NewAnalyticsUC(repo, baseCurrency(), envDuration("ANALYTICS_CACHE_TTL", time.Minute))
+	httpCfg.Analytics = analytics
+	// uploads must survive restarts for their jobs to resume, a temp dir would not
+	httpCfg.ImportDir = os.Getenv("IMPORT_DIR")
+	if httpCfg.ImportDir == "" {
+		log.Println("⚠️  IMPORT_DIR is not set, uploads to /admin/imports are disabled")
+	}
+	imports := usecase.NewImportUC(repo, uc, codec.NewJSONDecoder(codec.DefaultUpcasters(), true), envInt("IMPORT_BATCH_SIZE", usecase.DefaultImportBatch), httpCfg.ImportDir)
+	httpCfg.Imports = imports
+	httpCfg.MaxUploadBytes = int64(envInt("IMPORT_MAX_UPLOAD_BYTES", 1<<30))
+	consumerCfg.Gate = kafkaC.NewGate(kafkaC.GateConfig{
+		Check:         repo.Ping,
+		CheckInterval: envDuration("CONSUMER_HEALTH_INTERVAL", 5*time.Second),
+		ErrorRate:     envFloat("CONSUMER_PAUSE_ERROR_RATE", 0.5),
+		MinSamples:    envInt("CONSUMER_PAUSE_MIN_SAMPLES", 20),
+		Window:        envDuration("CONSUMER_PAUSE_WINDOW", 30*time.Second),
+		Backoff:       envDuration("CONSUMER_RESUME_BACKOFF", time.Second),
+		MaxBackoff:    envDuration("CONSUMER_RESUME_MAX_BACKOFF", time.Minute),
+	})
+	httpCfg.Consumer = consumerCfg.Gate
+	consumerCfg.Lag = kafkaC.NewLagMonitor(kafkaC.LagConfig{
+		Interval:  envDuration("KAFKA_LAG_INTERVAL", 15*time.Second),
+		Threshold: int64(envInt("KAFKA_LAG_ALERT", 10_000)),
+		For:       envDuration("KAFKA_LAG_ALERT_FOR", 5*time.Minute),
+	})
+	httpCfg.Lag = consumerCfg.Lag
 
 	if list, err := repo.CacheRestore(ctx); err == nil {
 		for _, o := range list {
@@ -53,26 +182,71 @@ func main() {
 	}
 
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(5)
 
 	// Start HTTP server
 	go func() {
 		defer wg.Done()
-		if err := http.Start(ctx, uc, httpAddr); err != nil {
+		if err := http.Start(ctx, uc, httpCfg); err != nil {
 			log.Printf("HTTP server error: %v", err)
 			stop() // Signal shutdown on error
 		}
 	}()
 
+	// Start gRPC server
+	go func() {
+		defer wg.Done()
+		if err := grpc.Start(ctx, uc, events, grpcAddr); err != nil {
+			log.Printf("gRPC server error: %v", err)
+			stop() // Signal shutdown on error
+		}
+	}()
+
+	// Start webhook sender
+	go func() {
+		defer wg.Done()
+		if err := webhook.NewSender(repo, webhookCfg).Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhook sender error: %v", err)
+			stop() // Signal shutdown on error
+		}
+	}()
+
+	// Start outbox relay
+	go func() {
+		defer wg.Done()
+		if err := relay.New(repo, relayCfg).Run(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("outbox relay error: %v", err)
+			stop() // Signal shutdown on error
+		}
+	}()
+
 	// Start Kafka consumer
 	go func() {
 		defer wg.Done()
-		if err := kafkaC.Start(ctx, uc, brokers, topic, groupID); err != nil {
+		if err := kafkaC.Start(ctx, consumerCfg, consumerRoutes(uc, decoders)...); err != nil {
 			log.Printf("Kafka consumer error: %v", err)
 			stop() // Signal shutdown on error
 		}
 	}()
 
+	// Start analytics views refresh, views are only refreshed by hand when disabled
+	if interval := envDuration("ANALYTICS_REFRESH_INTERVAL", 15*time.Minute); interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = analytics.Run(ctx, interval)
+		}()
+	}
+
+	// Start import workers, uploaded jobs stay queued when disabled
+	if interval := envDuration("IMPORT_POLL_INTERVAL", 5*time.Second); interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = imports.Run(ctx, interval)
+		}()
+	}
+
 	// Wait for shutdown signal
 	<-ctx.Done()
 	log.Println("Shutting down...")
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"order-service/internal/adapter/db"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rates loads exchange rates quoted in BASE_CURRENCY from a file.
+//
+//	app rates -file rates.csv
+//
+// CSV files have a currency,date,rate header, JSON files hold an array of
+// {"currency": "USD", "date": "2025-07-01", "rate": "78.45"} objects. Dates
+// are YYYY-MM-DD, a rate is the price of one unit of the currency in the
+// base currency.
+func rates(args []string) {
+	fs := flag.NewFlagSet("rates", flag.ExitOnError)
+	file := fs.String("file", "", "CSV or JSON file with rates")
+	dryRun := fs.Bool("dry-run", false, "only parse the file")
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	list, err := readRates(*file)
+	if err != nil {
+		log.Fatalf("%s: %v", *file, err)
+	}
+	if *dryRun {
+		log.Printf("%s: %d rates", *file, len(list))
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pgDsn := os.Getenv("PG_DSN")
+	if err := db.RunMigrations(pgDsn); err != nil {
+		log.Fatalf("failed to run migrations: %v", err)
+	}
+	repo, err := db.NewPgRepo(pgDsn)
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+
+	uc := usecase.NewRatesUC(repo, baseCurrency())
+	n, err := uc.Load(ctx, list)
+	if err != nil {
+		log.Fatalf("rates: %v", err)
+	}
+	log.Printf("loaded %d rates to %s", n, uc.Base())
+}
+
+type rateRecord struct {
+	Currency string      `json:"currency"`
+	Date     string      `json:"date"`
+	Rate     json.Number `json:"rate"`
+}
+
+func readRates(path string) ([]domain.ExchangeRate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []rateRecord
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return nil, err
+		}
+	} else if records, err = readRateCSV(f); err != nil {
+		return nil, err
+	}
+
+	list := make([]domain.ExchangeRate, 0, len(records))
+	for i, rec := range records {
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(rec.Date))
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(rec.Rate.String()))
+		if !ok {
+			return nil, fmt.Errorf("record %d: bad rate %q", i+1, rec.Rate)
+		}
+		list = append(list, domain.ExchangeRate{Currency: rec.Currency, Date: date, Rate: rate})
+	}
+	return list, nil
+}
+
+func readRateCSV(r io.Reader) ([]rateRecord, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"currency", "date", "rate"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("header lacks a %s column", name)
+		}
+	}
+
+	var records []rateRecord
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rateRecord{
+			Currency: row[col["currency"]],
+			Date:     row[col["date"]],
+			Rate:     json.Number(row[col["rate"]]),
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"order-service/cmd/kafkaC"
+	"order-service/internal/adapter/cache"
+	"order-service/internal/adapter/db"
+	"order-service/internal/broker"
+	"order-service/internal/usecase"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// replay re-ingests a range of the orders topic, see kafkaC.Replay.
+//
+//	app replay -from-time 2025-07-01T00:00:00Z -to-time 2025-07-02T00:00:00Z -dry-run
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	topic := fs.String("topic", os.Getenv("KAFKA_TOPIC"), "topic to replay")
+	partitions := fs.String("partitions", "", "comma separated partitions, all by default")
+	fromOffset := fs.Int64("from-offset", kafka.FirstOffset, "first offset to replay")
+	toOffset := fs.Int64("to-offset", 0, "stop before this offset, high watermark by default")
+	fromTime := fs.String("from-time", "", "replay messages from this RFC3339 time, overrides -from-offset")
+	toTime := fs.String("to-time", "", "stop after this RFC3339 time")
+	dryRun := fs.Bool("dry-run", false, "only report what would change")
+	_ = fs.Parse(args)
+
+	cfg := kafkaC.ReplayConfig{
+		Conn:       newKafkaConn(),
+		Topic:      *topic,
+		FromOffset: *fromOffset,
+		ToOffset:   *toOffset,
+		DryRun:     *dryRun,
+		Decoders:   newDecoders(),
+	}
+	for _, p := range strings.Split(*partitions, ",") {
+		if p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			log.Fatalf("bad partition %q", p)
+		}
+		cfg.Partitions = append(cfg.Partitions, n)
+	}
+	var err error
+	if *fromTime != "" {
+		if cfg.FromTime, err = time.Parse(time.RFC3339, *fromTime); err != nil {
+			log.Fatalf("bad -from-time: %v", err)
+		}
+	}
+	if *toTime != "" {
+		if cfg.ToTime, err = time.Parse(time.RFC3339, *toTime); err != nil {
+			log.Fatalf("bad -to-time: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repo, err := db.NewPgRepo(os.Getenv("PG_DSN"))
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+	uc := usecase.NewOrederUC(repo, cache.NewMemCache(0), broker.New(0, 0))
+
+	stats, err := kafkaC.Replay(ctx, uc, cfg)
+	if stats != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(stats)
+	}
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+}
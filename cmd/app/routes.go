@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log"
+	"order-service/cmd/kafkaC"
+	"order-service/internal/codec"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+	"os"
+	"time"
+)
+
+// consumerRoutes binds the orders topic and the optional event topics to
+// usecase methods. An event topic is consumed only when its
+// KAFKA_<NAME>_TOPIC variable is set.
+func consumerRoutes(uc *usecase.OrderUC, decoders *codec.Decoders) []kafkaC.Route {
+	routes := []kafkaC.Route{{
+		Topic:       os.Getenv("KAFKA_TOPIC"),
+		Workers:     envInt("KAFKA_WORKERS", 8),
+		QueueSize:   envInt("KAFKA_WORKER_QUEUE", 64),
+		BatchSize:   envInt("KAFKA_BATCH_SIZE", 500),
+		BatchWindow: envDuration("KAFKA_BATCH_WINDOW", 200*time.Millisecond),
+		Attempts:    envInt("KAFKA_ATTEMPTS", 3),
+		OnError:     envErrorPolicy("KAFKA_ON_ERROR", kafkaC.Skip),
+		Handler:     kafkaC.Batched(kafkaC.Orders(decoders), uc.SetBatch, uc.Set),
+	}}
+
+	events := []struct {
+		name    string
+		handler kafkaC.Handler
+	}{
+		{"PAYMENTS", kafkaC.Each(kafkaC.JSON[domain.PaymentConfirmation](), uc.ConfirmPayment)},
+		{"DELIVERY", kafkaC.Each(kafkaC.JSON[domain.StatusUpdate](), uc.UpdateDeliveryStatus)},
+		{"CANCELLATIONS", kafkaC.Each(kafkaC.JSON[domain.Cancellation](), uc.Cancel)},
+	}
+	for _, ev := range events {
+		prefix := "KAFKA_" + ev.name + "_"
+		topic := os.Getenv(prefix + "TOPIC")
+		if topic == "" {
+			continue
+		}
+		// updates may overtake their order, parked ones are replayed later
+		routes = append(routes, kafkaC.Route{
+			Topic:     topic,
+			Workers:   envInt(prefix+"WORKERS", 2),
+			QueueSize: envInt(prefix+"WORKER_QUEUE", 64),
+			Attempts:  envInt(prefix+"ATTEMPTS", 3),
+			OnError:   envErrorPolicy(prefix+"ON_ERROR", kafkaC.Park),
+			Handler:   ev.handler,
+		})
+	}
+	return routes
+}
+
+func envErrorPolicy(key string, def kafkaC.ErrorPolicy) kafkaC.ErrorPolicy {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	p, err := kafkaC.ParseErrorPolicy(v)
+	if err != nil {
+		log.Printf("⚠️  bad %s=%q, using %s", key, v, def)
+		return def
+	}
+	return p
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"order-service/internal/adapter/db"
+	"order-service/internal/domain"
+	"order-service/internal/fake"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// seed fills the database with generated orders of a fake profile.
+//
+//	app seed -count 10000 -profile returns-heavy -seed 42
+func seed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := fs.Int("count", 1000, "orders to insert")
+	profile := fs.String("profile", "default", "scenario profile of generated orders")
+	profiles := fs.String("profiles", "", "YAML file with scenario profiles, the built-in ones by default")
+	seedValue := fs.Int64("seed", 0, "random seed for reproducible orders, 0 picks one from the clock")
+	batch := fs.Int("batch", 500, "orders per insert")
+	_ = fs.Parse(args)
+
+	prof, err := fake.LookupProfile(*profiles, *profile)
+	if err != nil {
+		log.Fatalf("bad -profile: %v", err)
+	}
+	if *seedValue == 0 {
+		*seedValue = time.Now().UnixNano()
+	}
+	if *batch < 1 {
+		*batch = 1
+	}
+	gen, err := fake.New(*seedValue, prof)
+	if err != nil {
+		log.Fatalf("bad -profile: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	repo, err := db.NewPgRepo(os.Getenv("PG_DSN"))
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+
+	log.Printf("seeding %d orders of profile %s, seed %d", *count, prof.Name, *seedValue)
+	for done := 0; done < *count && ctx.Err() == nil; {
+		n := min(*batch, *count-done)
+		orders := make([]*domain.Order, n)
+		for i := range orders {
+			ord := gen.Order()
+			orders[i] = &ord
+		}
+		// straight to the tables, the outbox would publish fake orders
+		if _, err := repo.SeedBatch(ctx, orders); err != nil {
+			log.Fatalf("seed: %v after %d orders", err, done)
+		}
+		done += n
+	}
+}
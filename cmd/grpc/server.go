@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"log"
+	"net"
+	orderv1 "order-service/api/order/v1"
+	"order-service/internal/broker"
+	"order-service/internal/codec"
+	"order-service/internal/usecase"
+)
+
+type orderServer struct {
+	orderv1.UnimplementedOrderServiceServer
+	uc     *usecase.OrderUC
+	events *broker.Broker
+}
+
+// NewServer builds a gRPC server with the order service, health checking and reflection.
+// The returned health server is flipped to NOT_SERVING on shutdown.
+func NewServer(uc *usecase.OrderUC, events *broker.Broker) (*grpc.Server, *health.Server) {
+	s := grpc.NewServer()
+	orderv1.RegisterOrderServiceServer(s, &orderServer{uc: uc, events: events})
+
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus(orderv1.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	reflection.Register(s)
+	return s, hs
+}
+
+func Start(ctx context.Context, uc *usecase.OrderUC, events *broker.Broker, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("gRPC API listening on %s", addr)
+
+	s, hs := NewServer(uc, events)
+
+	go func() {
+		<-ctx.Done()
+		hs.Shutdown()
+		s.GracefulStop()
+	}()
+
+	if err := s.Serve(lis); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (s *orderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.Order, error) {
+	log.Printf("gRPC GetOrder %s", req.GetOrderUid())
+
+	if _, err := uuid.Parse(req.GetOrderUid()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	obj, err := s.uc.Get(ctx, req.GetOrderUid())
+	if err != nil {
+		log.Printf("gRPC INTERNAL ERROR: %v", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	if obj == nil {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+
+	return codec.OrderToProto(obj), nil
+}
+
+func (s *orderServer) ListOrders(ctx context.Context, req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+	log.Printf("gRPC ListOrders customer=%q limit=%d", req.GetCustomerId(), req.GetLimit())
+
+	list, err := s.uc.List(ctx, req.GetCustomerId(), int(req.GetLimit()))
+	if err != nil {
+		log.Printf("gRPC INTERNAL ERROR: %v", err)
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	resp := &orderv1.ListOrdersResponse{Orders: make([]*orderv1.Order, 0, len(list))}
+	for _, o := range list {
+		resp.Orders = append(resp.Orders, codec.OrderToProto(o))
+	}
+	return resp, nil
+}
+
+func (s *orderServer) CreateOrder(ctx context.Context, req *orderv1.CreateOrderRequest) (*orderv1.Order, error) {
+	if req.GetOrder() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing order")
+	}
+
+	ord, err := codec.OrderFromProto(req.GetOrder())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := ord.CheckTotals(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("gRPC CreateOrder %s", ord.OrderId)
+
+	if err := s.uc.Set(ctx, ord); err != nil {
+		log.Printf("gRPC INTERNAL ERROR: %v", err)
+		return nil, status.Error(codes.Internal, "save failed")
+	}
+
+	return codec.OrderToProto(ord), nil
+}
+
+func (s *orderServer) WatchOrders(req *orderv1.WatchOrdersRequest, stream orderv1.OrderService_WatchOrdersServer) error {
+	log.Printf("gRPC WatchOrders customer=%q delivery_service=%q", req.GetCustomerId(), req.GetDeliveryService())
+
+	sub := s.events.Subscribe(stream.Context(), 0)
+	for ev := range sub.C {
+		o := ev.Order
+		if req.GetCustomerId() != "" && o.CustomerId != req.GetCustomerId() {
+			continue
+		}
+		if req.GetDeliveryService() != "" && o.DeliveryService != req.GetDeliveryService() {
+			continue
+		}
+		if err := stream.Send(codec.OrderToProto(o)); err != nil {
+			return err
+		}
+	}
+	if sub.Lagged() {
+		return status.Error(codes.ResourceExhausted, "client is too slow, resubscribe")
+	}
+	return nil
+}
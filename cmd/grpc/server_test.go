@@ -0,0 +1,283 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	orderv1 "order-service/api/order/v1"
+	"order-service/internal/adapter/cache"
+	"order-service/internal/broker"
+	"order-service/internal/codec"
+	"order-service/internal/domain"
+	"order-service/internal/fake"
+	"order-service/internal/repository"
+	"order-service/internal/usecase"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memRepo keeps orders in a map, methods the server does not reach panic
+// through the nil embedded interface.
+type memRepo struct {
+	repository.OrderRepository
+	mu     sync.Mutex
+	orders map[string]*domain.Order
+}
+
+func (r *memRepo) Find(ctx context.Context, id string) (*domain.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.orders[id], nil
+}
+
+func (r *memRepo) Save(ctx context.Context, order *domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.OrderId.String()] = order
+	return nil
+}
+
+func (r *memRepo) FindRecent(ctx context.Context, limit int) ([]*domain.Order, error) {
+	return r.FindRecentByCustomer(ctx, "", limit)
+}
+
+func (r *memRepo) FindRecentByCustomer(ctx context.Context, customerID string, limit int) ([]*domain.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*domain.Order, 0)
+	for _, o := range r.orders {
+		if customerID == "" || o.CustomerId == customerID {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].DateCreated.After(list[j].DateCreated) })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+type testServer struct {
+	client orderv1.OrderServiceClient
+	health healthpb.HealthClient
+	hs     *health.Server
+	repo   *memRepo
+	events *broker.Broker
+}
+
+func newTestServer(t *testing.T, orders ...domain.Order) *testServer {
+	t.Helper()
+
+	repo := &memRepo{orders: make(map[string]*domain.Order)}
+	for i := range orders {
+		repo.orders[orders[i].OrderId.String()] = &orders[i]
+	}
+	events := broker.New(0, 16)
+	uc := usecase.NewOrederUC(repo, cache.NewMemCache(100), events)
+
+	lis := bufconn.Listen(1 << 20)
+	s, hs := NewServer(uc, events)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{
+		client: orderv1.NewOrderServiceClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		hs:     hs,
+		repo:   repo,
+		events: events,
+	}
+}
+
+func fakeOrders(t *testing.T, n int) []domain.Order {
+	t.Helper()
+	profiles, err := fake.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := fake.New(1, profiles[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.Orders(n)
+}
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("got error %v, want code %s", err, code)
+	}
+}
+
+func TestGetOrder(t *testing.T) {
+	orders := fakeOrders(t, 1)
+	ts := newTestServer(t, orders...)
+	ctx := context.Background()
+
+	got, err := ts.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderUid: orders[0].OrderId.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := codec.OrderFromProto(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.OrderId != orders[0].OrderId || o.CustomerId != orders[0].CustomerId || len(o.Items) != len(orders[0].Items) {
+		t.Errorf("got order %s of %s with %d items, want %s of %s with %d items",
+			o.OrderId, o.CustomerId, len(o.Items), orders[0].OrderId, orders[0].CustomerId, len(orders[0].Items))
+	}
+	if o.Payment.Amount != orders[0].Payment.Amount {
+		t.Errorf("got amount %s, want %s", o.Payment.Amount, orders[0].Payment.Amount)
+	}
+
+	_, err = ts.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderUid: "not-a-uuid"})
+	wantCode(t, err, codes.InvalidArgument)
+
+	_, err = ts.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderUid: fakeOrders(t, 2)[1].OrderId.String()})
+	wantCode(t, err, codes.NotFound)
+}
+
+func TestListOrders(t *testing.T) {
+	orders := fakeOrders(t, 5)
+	base := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for i := range orders {
+		orders[i].DateCreated = base.Add(time.Duration(i) * time.Hour)
+		orders[i].CustomerId = "alice"
+	}
+	orders[4].CustomerId = "bob"
+	ts := newTestServer(t, orders...)
+	ctx := context.Background()
+
+	resp, err := ts.client.ListOrders(ctx, &orderv1.ListOrdersRequest{CustomerId: "alice", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0)
+	for _, o := range resp.GetOrders() {
+		got = append(got, o.GetOrderUid())
+	}
+	want := []string{orders[3].OrderId.String(), orders[2].OrderId.String()}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got orders %v, want the latest of alice %v", got, want)
+	}
+
+	resp, err = ts.client.ListOrders(ctx, &orderv1.ListOrdersRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(resp.GetOrders()); n != len(orders) {
+		t.Errorf("got %d orders without a filter, want %d", n, len(orders))
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	order := fakeOrders(t, 1)[0]
+
+	got, err := ts.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{Order: codec.OrderToProto(&order)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetOrderUid() != order.OrderId.String() {
+		t.Errorf("got order %s, want %s", got.GetOrderUid(), order.OrderId)
+	}
+	if stored, _ := ts.repo.Find(ctx, order.OrderId.String()); stored == nil {
+		t.Error("order was not saved")
+	}
+
+	_, err = ts.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{})
+	wantCode(t, err, codes.InvalidArgument)
+
+	bad := codec.OrderToProto(&order)
+	bad.OrderUid = "not-a-uuid"
+	_, err = ts.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{Order: bad})
+	wantCode(t, err, codes.InvalidArgument)
+
+	order.Payment.GoodsTotal = order.Payment.GoodsTotal.Mul(2)
+	_, err = ts.client.CreateOrder(ctx, &orderv1.CreateOrderRequest{Order: codec.OrderToProto(&order)})
+	wantCode(t, err, codes.InvalidArgument)
+}
+
+func TestWatchOrders(t *testing.T) {
+	ts := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orders := fakeOrders(t, 2)
+	want, other := &orders[0], &orders[1]
+	other.CustomerId = want.CustomerId + "-other"
+
+	stream, err := ts.client.WatchOrders(ctx, &orderv1.WatchOrdersRequest{CustomerId: want.CustomerId})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the server subscribes after the call returns, publish until it listens
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		tick := time.NewTicker(10 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				ts.events.Publish(usecase.EventOrderSaved, other)
+				ts.events.Publish(usecase.EventOrderSaved, want)
+			}
+		}
+	}()
+
+	got, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetOrderUid() != want.OrderId.String() {
+		t.Errorf("got order %s, want %s of the watched customer", got.GetOrderUid(), want.OrderId)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	for _, service := range []string{"", orderv1.OrderService_ServiceDesc.ServiceName} {
+		resp, err := ts.health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("service %q is %s, want SERVING", service, resp.GetStatus())
+		}
+	}
+
+	ts.hs.Shutdown()
+	resp, err := ts.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("got %s after shutdown, want NOT_SERVING", resp.GetStatus())
+	}
+
+	_, err = ts.health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	wantCode(t, err, codes.NotFound)
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// adminOnly guards admin routes with a static bearer token.
+// An empty token leaves them open, which is only meant for local runs.
+func adminOnly(token string) func(http.Handler) http.Handler {
+	if token == "" {
+		log.Println("⚠️  ADMIN_TOKEN is empty, admin endpoints are not protected")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token != "" {
+				got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+					log.Printf("UNAUTHORIZED: %s %s", r.Method, r.URL.Path)
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
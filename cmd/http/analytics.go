@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+	"strconv"
+	"time"
+)
+
+// analyticsReport is the envelope of the analytics endpoints, amounts are
+// in Currency.
+type analyticsReport struct {
+	Currency string    `json:"currency"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Rows     any       `json:"rows,omitempty"`
+	Summary  any       `json:"summary,omitempty"`
+}
+
+// analyticsRoutes serves
+//
+//	GET /analytics/sales?group_by=provider&period=week&from=2025-07-01&to=2025-07-31
+//	GET /analytics/top?by=brand|nm_id&limit=10
+//	GET /analytics/summary
+//
+// from and to are inclusive YYYY-MM-DD days, the last 30 days by default.
+func analyticsRoutes(uc *usecase.AnalyticsUC) func(chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/sales", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /analytics/sales")
+
+			q, ok := analyticsQuery(w, r, uc)
+			if !ok {
+				return
+			}
+			rows, err := uc.Sales(r.Context(), q, r.URL.Query().Get("group_by"), r.URL.Query().Get("period"))
+			if !analyticsOK(w, err) {
+				return
+			}
+			writeJSON(w, http.StatusOK, analyticsReport{Currency: q.Currency, From: q.From, To: q.To, Rows: rows})
+		})
+
+		r.Get("/top", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /analytics/top")
+
+			q, ok := analyticsQuery(w, r, uc)
+			if !ok {
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			rows, err := uc.TopSales(r.Context(), q, r.URL.Query().Get("by"), limit)
+			if !analyticsOK(w, err) {
+				return
+			}
+			writeJSON(w, http.StatusOK, analyticsReport{Currency: q.Currency, From: q.From, To: q.To, Rows: rows})
+		})
+
+		r.Get("/summary", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /analytics/summary")
+
+			q, ok := analyticsQuery(w, r, uc)
+			if !ok {
+				return
+			}
+			s, err := uc.Summary(r.Context(), q)
+			if !analyticsOK(w, err) {
+				return
+			}
+			writeJSON(w, http.StatusOK, analyticsReport{Currency: q.Currency, From: q.From, To: q.To, Summary: s})
+		})
+	}
+}
+
+func analyticsQuery(w http.ResponseWriter, r *http.Request, uc *usecase.AnalyticsUC) (domain.AnalyticsQuery, bool) {
+	var days [2]time.Time
+	for i, name := range []string{"from", "to"} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			http.Error(w, "bad "+name+", want YYYY-MM-DD", http.StatusBadRequest)
+			return domain.AnalyticsQuery{}, false
+		}
+		days[i] = d
+	}
+
+	q, err := uc.Query(days[0], days[1])
+	return q, analyticsOK(w, err)
+}
+
+func analyticsOK(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, usecase.ErrBadAnalyticsQuery):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("INTERNAL ERROR: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+	return false
+}
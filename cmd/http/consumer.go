@@ -0,0 +1,84 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+	"order-service/cmd/kafkaC"
+)
+
+func consumerRoutes(gate *kafkaC.Gate, lag *kafkaC.LagMonitor) func(chi.Router) {
+	return func(r chi.Router) {
+		if lag != nil {
+			r.Get("/lag", func(w http.ResponseWriter, r *http.Request) {
+				log.Printf("REQUEST: GET /admin/consumer/lag")
+				writeJSON(w, http.StatusOK, lag.State())
+			})
+		}
+		if gate == nil {
+			return
+		}
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /admin/consumer")
+			writeJSON(w, http.StatusOK, gate.State())
+		})
+
+		r.Post("/pause", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: POST /admin/consumer/pause")
+
+			var body struct {
+				Reason string `json:"reason"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					http.Error(w, "bad json", http.StatusBadRequest)
+					return
+				}
+			}
+
+			gate.Pause(body.Reason)
+			writeJSON(w, http.StatusOK, gate.State())
+		})
+
+		r.Post("/resume", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: POST /admin/consumer/resume")
+			gate.Resume()
+			writeJSON(w, http.StatusOK, gate.State())
+		})
+	}
+}
+
+// serveReady reports 503 while the consumer is paused, so the orchestrator
+// stops routing traffic to an instance that cannot ingest. A lagging
+// instance is only marked degraded, it still serves reads correctly and
+// taking every lagging replica out would take the whole API down.
+func serveReady(gate *kafkaC.Gate, lag *kafkaC.LagMonitor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := struct {
+			Status   string            `json:"status"`
+			Consumer *kafkaC.GateState `json:"consumer,omitempty"`
+			Lag      *kafkaC.LagState  `json:"lag,omitempty"`
+		}{Status: "ready"}
+
+		code := http.StatusOK
+		if lag != nil {
+			state := lag.State()
+			state.Partitions = nil
+			resp.Lag = &state
+			if state.Degraded {
+				resp.Status = "degraded"
+			}
+		}
+		if gate != nil {
+			state := gate.State()
+			resp.Consumer = &state
+			if state.Paused {
+				resp.Status = "paused"
+				code = http.StatusServiceUnavailable
+			}
+		}
+		writeJSON(w, code, resp)
+	}
+}
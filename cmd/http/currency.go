@@ -0,0 +1,51 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+)
+
+// orderView is an order with its payment valued in the display currency.
+type orderView struct {
+	*domain.Order
+	Display *domain.Valuation `json:"display,omitempty"`
+}
+
+// orderViews values the orders in ?display_currency= at the rates of their
+// dates. Without the parameter the views marshal as the bare orders. On
+// failure the error response is written and ok is false.
+func orderViews(w http.ResponseWriter, r *http.Request, rates *usecase.RatesUC, orders ...*domain.Order) (views []orderView, ok bool) {
+	currency := r.URL.Query().Get("display_currency")
+	views = make([]orderView, len(orders))
+	for i, o := range orders {
+		views[i].Order = o
+	}
+	if currency == "" {
+		return views, true
+	}
+	if rates == nil {
+		http.Error(w, "currency conversion is not configured", http.StatusBadRequest)
+		return nil, false
+	}
+
+	for i, o := range orders {
+		v, err := rates.Value(r.Context(), o, currency)
+		switch {
+		case errors.Is(err, usecase.ErrBadCurrency):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		case errors.Is(err, usecase.ErrNoRate):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return nil, false
+		case err != nil:
+			log.Printf("INTERNAL ERROR: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return nil, false
+		}
+		views[i].Display = v
+	}
+	return views, true
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/export"
+	"order-service/internal/usecase"
+	"strconv"
+	"time"
+)
+
+// serveExport streams orders as a file
+//
+//	GET /orders/export?format=csv|ndjson|xlsx&customer=&delivery_service=&currency=&from=2025-07-01&to=2025-07-31&columns=order_uid,item_price&mask=true
+//
+// from and to are inclusive YYYY-MM-DD days of date_created, columns pick
+// and order the columns of CSV and XLSX, mask hides delivery PII.
+func serveExport(uc *usecase.OrderUC) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("REQUEST: GET /orders/export")
+
+		q := r.URL.Query()
+		format := q.Get("format")
+		if format == "" {
+			format = export.FormatCSV
+		}
+		cols, err := export.ParseColumns(q.Get("columns"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mask := false
+		if v := q.Get("mask"); v != "" {
+			if mask, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, "bad mask, want true or false", http.StatusBadRequest)
+				return
+			}
+		}
+
+		f := domain.ExportFilter{
+			CustomerID:      q.Get("customer"),
+			DeliveryService: q.Get("delivery_service"),
+			Currency:        q.Get("currency"),
+		}
+		for _, d := range []struct {
+			name string
+			dst  *time.Time
+			days int
+		}{{"from", &f.From, 0}, {"to", &f.To, 1}} {
+			v := q.Get(d.name)
+			if v == "" {
+				continue
+			}
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				http.Error(w, "bad "+d.name+", want YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			// to is inclusive, the filter ends at the next midnight
+			*d.dst = t.AddDate(0, 0, d.days)
+		}
+
+		out, err := export.New(format, w, export.Options{Columns: cols, Mask: mask})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rc := http.NewResponseController(w)
+		// large exports outlive the server write timeout
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("EXPORT: cannot reset write deadline: %v", err)
+		}
+
+		started := false
+		n, err := uc.Export(r.Context(), f, func(o *domain.Order) error {
+			if !started {
+				started = true
+				exportHeaders(w, format)
+			}
+			return out.Write(o)
+		})
+		if err == nil && !started {
+			exportHeaders(w, format)
+		}
+		if err == nil {
+			err = out.Close()
+		}
+
+		switch {
+		case err == nil:
+			log.Printf("RESPONSE OK: exported %d orders", n)
+		case started:
+			// the status is out, cut the connection so the file is not taken as complete
+			log.Printf("EXPORT ERROR after %d orders: %v", n, err)
+			panic(http.ErrAbortHandler)
+		case errors.Is(err, usecase.ErrBadCurrency), errors.Is(err, usecase.ErrBadExportFilter):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("INTERNAL ERROR: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+	}
+}
+
+func exportHeaders(w http.ResponseWriter, format string) {
+	name := fmt.Sprintf("orders-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Cache-Control", "no-store")
+}
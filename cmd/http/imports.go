@@ -0,0 +1,193 @@
+package http
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/importer"
+	"order-service/internal/usecase"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// importRoutes serves
+//
+//	POST /admin/imports?format=csv|ndjson&dry_run=true   body: the file
+//	POST /admin/imports?name=orders.csv                  format by the extension
+//	GET  /admin/imports
+//	GET  /admin/imports/{id}
+//	GET  /admin/imports/{id}/errors?after=0&limit=100
+//	POST /admin/imports/{id}/resume
+//
+// Uploads are kept in dir and queued, the import workers pick them up.
+// Without a dir uploads are refused, jobs can still be run by app import.
+func importRoutes(uc *usecase.ImportUC, dir string, maxBytes int64) func(chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: POST /admin/imports")
+
+			if dir == "" {
+				http.Error(w, "uploads are disabled, IMPORT_DIR is not set", http.StatusServiceUnavailable)
+				return
+			}
+
+			q := r.URL.Query()
+			format := q.Get("format")
+			if format == "" {
+				format = importer.FormatOf(q.Get("name"))
+			}
+			if !slices.Contains(importer.Formats, format) {
+				http.Error(w, importer.ErrUnknownFormat.Error(), http.StatusBadRequest)
+				return
+			}
+			dryRun, _ := strconv.ParseBool(q.Get("dry_run"))
+
+			// uploads outlive the server read timeout
+			if err := http.NewResponseController(w).SetReadDeadline(time.Time{}); err != nil {
+				log.Printf("IMPORT: cannot reset read deadline: %v", err)
+			}
+			if maxBytes > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			path, err := saveUpload(r.Body, dir, format)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+
+			job := &domain.ImportJob{Source: path, Format: format, DryRun: dryRun}
+			if err := uc.Submit(r.Context(), job); err != nil {
+				_ = os.Remove(path)
+				if errors.Is(err, usecase.ErrBadImport) {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, job)
+		})
+
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /admin/imports")
+
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			list, err := uc.Jobs(r.Context(), limit)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		})
+
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("REQUEST: GET /admin/imports/%d", id)
+
+			job, ok := findImportJob(w, r, uc, id)
+			if !ok {
+				return
+			}
+			writeJSON(w, http.StatusOK, job)
+		})
+
+		r.Get("/{id}/errors", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("REQUEST: GET /admin/imports/%d/errors", id)
+
+			if _, ok := findImportJob(w, r, uc, id); !ok {
+				return
+			}
+			after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			list, err := uc.Errors(r.Context(), id, after, limit)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		})
+
+		r.Post("/{id}/resume", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("REQUEST: POST /admin/imports/%d/resume", id)
+
+			if _, ok := findImportJob(w, r, uc, id); !ok {
+				return
+			}
+			ok, err := uc.Requeue(r.Context(), id)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "only failed jobs can be resumed", http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		})
+	}
+}
+
+func findImportJob(w http.ResponseWriter, r *http.Request, uc *usecase.ImportUC, id int64) (*domain.ImportJob, bool) {
+	job, err := uc.Job(r.Context(), id)
+	if err != nil {
+		log.Printf("INTERNAL ERROR: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if job == nil {
+		http.Error(w, "Import job not found", http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+// saveUpload copies the body into a new file of dir, the job reads it from
+// there and can be resumed from it.
+func saveUpload(body io.Reader, dir, format string) (string, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "import-*."+format)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(f, body); err == nil {
+		err = f.Close()
+	} else {
+		_ = f.Close()
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"order-service/internal/metrics"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const clientIdleTTL = 10 * time.Minute
+
+type client struct {
+	lim      *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter is a token bucket per client IP. Headers such as an API key
+// are not authenticated, keying on them would let a client pick a fresh
+// bucket for every request.
+type rateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*client
+	limit   rate.Limit
+	burst   int
+}
+
+func newRateLimiter(ctx context.Context, rps float64, burst int) *rateLimiter {
+	rl := &rateLimiter{
+		clients: make(map[string]*client),
+		limit:   rate.Limit(rps),
+		burst:   burst,
+	}
+	go rl.cleanup(ctx)
+	return rl
+}
+
+func (rl *rateLimiter) get(key string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	c, ok := rl.clients[key]
+	if !ok {
+		c = &client{lim: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.clients[key] = c
+	}
+	c.lastSeen = time.Now()
+	return c.lim
+}
+
+// cleanup drops buckets of clients not seen for a while so the map does not grow forever.
+func (rl *rateLimiter) cleanup(ctx context.Context) {
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			rl.mu.Lock()
+			for key, c := range rl.clients {
+				if time.Since(c.lastSeen) > clientIdleTTL {
+					delete(rl.clients, key)
+				}
+			}
+			rl.mu.Unlock()
+		}
+	}
+}
+
+func (rl *rateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := clientKey(r)
+
+		res := rl.get(key).Reserve()
+		if delay := res.Delay(); !res.OK() || delay > 0 {
+			res.Cancel()
+			retry := int(math.Ceil(delay.Seconds()))
+			if retry < 1 {
+				retry = 1
+			}
+			log.Printf("RATE LIMITED: %s %s (%s)", r.Method, r.URL.Path, key)
+			metrics.HTTPRateLimited.Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func limitBody(max int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, max)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
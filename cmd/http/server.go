@@ -3,21 +3,80 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log"
 	"net/http"
+	"order-service/cmd/kafkaC"
+	"order-service/internal/broker"
+	"order-service/internal/domain"
 	"order-service/internal/usecase"
+	"time"
 )
 
-func Start(ctx context.Context, uc *usecase.OrderUC, addr string) error {
-	log.Printf("HTTP API listening on %s  –  press Ctrl-C to stop", addr)
+type Config struct {
+	Addr string
 
-	r := chi.NewRouter()
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// MaxBodyBytes caps request bodies, zero disables the limit.
+	MaxBodyBytes int64
+
+	// RateLimit is the per-client refill rate in requests per second,
+	// zero disables rate limiting.
+	RateLimit float64
+	RateBurst int
+
+	// Events feeds the live order stream, admins only, nil disables the stream endpoints.
+	Events *broker.Broker
+
+	// AdminToken protects /admin routes, see adminOnly.
+	AdminToken string
+	// Webhooks backs the webhook admin endpoints, nil disables them.
+	Webhooks *usecase.WebhookUC
+	// Consumer backs /readyz and the consumer pause endpoints, nil leaves
+	// the instance always ready.
+	Consumer *kafkaC.Gate
+	// Lag backs the consumer lag endpoint and marks /readyz degraded, nil disables both.
+	Lag *kafkaC.LagMonitor
+	// Rates values orders in ?display_currency=, nil rejects the parameter.
+	Rates *usecase.RatesUC
+	// Analytics backs /analytics and the manual refresh, nil disables both.
+	Analytics *usecase.AnalyticsUC
+	// Imports backs /admin/imports, nil disables it. Uploads are stored in
+	// ImportDir, which has to outlive restarts, empty refuses them. They are
+	// capped by MaxUploadBytes instead of MaxBodyBytes.
+	Imports        *usecase.ImportUC
+	ImportDir      string
+	MaxUploadBytes int64
+}
+
+func Start(ctx context.Context, uc *usecase.OrderUC, cfg Config) error {
+	log.Printf("HTTP API listening on %s  –  press Ctrl-C to stop", cfg.Addr)
+
+	root := chi.NewRouter()
 
 	// Serve static files
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+	root.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/index.html")
 	})
+	root.Handle("/metrics", promhttp.Handler())
+	root.Get("/readyz", serveReady(cfg.Consumer, cfg.Lag))
+
+	var r chi.Router = root
+	if cfg.RateLimit > 0 {
+		r = root.With(newRateLimiter(ctx, cfg.RateLimit, cfg.RateBurst).Middleware)
+	}
+	// uploads have their own limit
+	uploads := r
+	if cfg.MaxBodyBytes > 0 {
+		r = r.With(limitBody(cfg.MaxBodyBytes))
+	}
 
 	r.Get("/order/{uid}", func(w http.ResponseWriter, r *http.Request) {
 		uid := chi.URLParam(r, "uid")
@@ -42,7 +101,12 @@ func Start(ctx context.Context, uc *usecase.OrderUC, addr string) error {
 			return
 		}
 
-		pretty, err := json.MarshalIndent(obj, "", "  ")
+		views, ok := orderViews(w, r, cfg.Rates, obj)
+		if !ok {
+			return
+		}
+
+		pretty, err := json.MarshalIndent(views[0], "", "  ")
 		if err != nil {
 			log.Printf("ENCODE ERROR: %v", err)
 			http.Error(w, "encode error", http.StatusInternalServerError)
@@ -54,7 +118,114 @@ func Start(ctx context.Context, uc *usecase.OrderUC, addr string) error {
 		w.Write(pretty)
 	})
 
-	srv := &http.Server{Addr: addr, Handler: r}
+	// customer data and its erasure are for support staff, not the public
+	admin := r.With(adminOnly(cfg.AdminToken))
+
+	admin.Get("/customers/{id}/export", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "id")
+		log.Printf("REQUEST: GET /customers/%s/export", customerID)
+
+		export, err := uc.ExportCustomer(r.Context(), customerID)
+		if err != nil {
+			if errors.Is(err, usecase.ErrEmptyCustomerID) {
+				http.Error(w, "missing id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("INTERNAL ERROR: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		if len(export.Orders) == 0 {
+			log.Printf("NOT FOUND: customer %s", customerID)
+			http.Error(w, "Customer not found", http.StatusNotFound)
+			return
+		}
+
+		views, ok := orderViews(w, r, cfg.Rates, export.Orders...)
+		if !ok {
+			return
+		}
+
+		pretty, err := json.MarshalIndent(struct {
+			*domain.CustomerExport
+			Orders []orderView `json:"orders"`
+		}{export, views}, "", "  ")
+		if err != nil {
+			log.Printf("ENCODE ERROR: %v", err)
+			http.Error(w, "encode error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "customer-"+customerID+"-export.json"))
+		log.Printf("RESPONSE OK: customer %s, %d orders", customerID, len(export.Orders))
+		w.Write(pretty)
+	})
+
+	admin.Delete("/customers/{id}/personal-data", func(w http.ResponseWriter, r *http.Request) {
+		customerID := chi.URLParam(r, "id")
+		log.Printf("REQUEST: DELETE /customers/%s/personal-data", customerID)
+
+		n, err := uc.EraseCustomer(r.Context(), customerID)
+		if err != nil {
+			if errors.Is(err, usecase.ErrEmptyCustomerID) {
+				http.Error(w, "missing id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("INTERNAL ERROR: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		log.Printf("RESPONSE OK: customer %s erased, %d orders", customerID, n)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"customer_id":     customerID,
+			"orders_affected": n,
+		})
+	})
+
+	// the feed names the order and customer of every incoming order
+	if cfg.Events != nil {
+		admin.Get("/orders/stream", serveSSE(cfg.Events))
+		admin.Get("/orders/ws", serveWS(cfg.Events))
+	}
+
+	// exports carry PII of many customers at once
+	admin.Get("/orders/export", serveExport(uc))
+	if cfg.Webhooks != nil {
+		admin.Route("/admin/webhooks", webhookRoutes(cfg.Webhooks))
+	}
+	if cfg.Consumer != nil || cfg.Lag != nil {
+		admin.Route("/admin/consumer", consumerRoutes(cfg.Consumer, cfg.Lag))
+	}
+	if cfg.Analytics != nil {
+		// revenue figures are for staff too
+		admin.Route("/analytics", analyticsRoutes(cfg.Analytics))
+		admin.Post("/admin/analytics/refresh", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: POST /admin/analytics/refresh")
+
+			if err := cfg.Analytics.Refresh(r.Context()); err != nil {
+				http.Error(w, "refresh failed", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	if cfg.Imports != nil {
+		uploads.With(adminOnly(cfg.AdminToken)).Route("/admin/imports", importRoutes(cfg.Imports, cfg.ImportDir, cfg.MaxUploadBytes))
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           root,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
 
 	go func() {
 		<-ctx.Done()
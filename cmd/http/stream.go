@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"order-service/internal/broker"
+	"order-service/internal/domain"
+	"strconv"
+	"time"
+)
+
+const (
+	streamHeartbeat = 15 * time.Second
+	wsWriteTimeout  = 10 * time.Second
+)
+
+type orderSummary struct {
+	OrderUid        string       `json:"order_uid"`
+	TrackNumber     string       `json:"track_number"`
+	CustomerId      string       `json:"customer_id"`
+	DeliveryService string       `json:"delivery_service"`
+	Amount          domain.Money `json:"amount"`
+	Currency        string       `json:"currency"`
+	Items           int          `json:"items"`
+	DateCreated     time.Time    `json:"date_created"`
+}
+
+type streamMessage struct {
+	ID    uint64       `json:"id"`
+	Type  string       `json:"type"`
+	Order orderSummary `json:"order"`
+}
+
+type streamFilter struct {
+	customerID      string
+	deliveryService string
+}
+
+func newStreamFilter(r *http.Request) streamFilter {
+	q := r.URL.Query()
+	return streamFilter{
+		customerID:      q.Get("customer_id"),
+		deliveryService: q.Get("delivery_service"),
+	}
+}
+
+func (f streamFilter) match(ev broker.Event) bool {
+	if f.customerID != "" && ev.Order.CustomerId != f.customerID {
+		return false
+	}
+	if f.deliveryService != "" && ev.Order.DeliveryService != f.deliveryService {
+		return false
+	}
+	return true
+}
+
+func newStreamMessage(ev broker.Event) streamMessage {
+	o := ev.Order
+	return streamMessage{
+		ID:   ev.ID,
+		Type: ev.Type,
+		Order: orderSummary{
+			OrderUid:        o.OrderId.String(),
+			TrackNumber:     o.TrackNumber,
+			CustomerId:      o.CustomerId,
+			DeliveryService: o.DeliveryService,
+			Amount:          o.Payment.Amount,
+			Currency:        o.Payment.Currency,
+			Items:           len(o.Items),
+			DateCreated:     o.DateCreated,
+		},
+	}
+}
+
+func lastEventID(v string) uint64 {
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// serveSSE streams order events as Server-Sent Events. Clients resume with
+// the standard Last-Event-ID header.
+func serveSSE(events *broker.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("REQUEST: GET /orders/stream")
+
+		rc := http.NewResponseController(w)
+		// the stream outlives the server write timeout
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			log.Printf("SSE: cannot reset write deadline: %v", err)
+		}
+
+		filter := newStreamFilter(r)
+		sub := events.Subscribe(r.Context(), lastEventID(r.Header.Get("Last-Event-ID")))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		_ = rc.Flush()
+
+		ping := time.NewTicker(streamHeartbeat)
+		defer ping.Stop()
+
+		for {
+			select {
+			case ev, ok := <-sub.C:
+				if !ok {
+					if sub.Lagged() {
+						// the client reconnects by itself and resumes from the replay buffer
+						log.Printf("SSE: client too slow, closing stream")
+					}
+					return
+				}
+				if !filter.match(ev) {
+					continue
+				}
+				payload, err := json.Marshal(newStreamMessage(ev))
+				if err != nil {
+					log.Printf("ENCODE ERROR: %v", err)
+					continue
+				}
+				if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload); err != nil {
+					return
+				}
+				if err = rc.Flush(); err != nil {
+					return
+				}
+			case <-ping.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// serveWS is the WebSocket flavour of serveSSE, the resume point is passed
+// as the last_event_id query parameter.
+func serveWS(events *broker.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("REQUEST: GET /orders/ws")
+
+		filter := newStreamFilter(r)
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WS upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		ctx := r.Context()
+		sub := events.Subscribe(ctx, lastEventID(r.URL.Query().Get("last_event_id")))
+
+		// read loop only serves control frames and notices the client going away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(streamHeartbeat)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case ev, ok := <-sub.C:
+				if !ok {
+					if sub.Lagged() {
+						log.Printf("WS: client too slow, closing stream")
+						msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, resume with last_event_id")
+						_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+					}
+					return
+				}
+				if !filter.match(ev) {
+					continue
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := conn.WriteJSON(newStreamMessage(ev)); err != nil {
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
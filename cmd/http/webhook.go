@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/usecase"
+	"strconv"
+)
+
+func webhookRoutes(uc *usecase.WebhookUC) func(chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: GET /admin/webhooks")
+
+			list, err := uc.Subscriptions(r.Context())
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		})
+
+		r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			log.Printf("REQUEST: POST /admin/webhooks")
+
+			var sub domain.WebhookSubscription
+			if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+				http.Error(w, "bad json", http.StatusBadRequest)
+				return
+			}
+
+			if err := uc.Subscribe(r.Context(), &sub); err != nil {
+				if errors.Is(err, usecase.ErrInvalidWebhookURL) || errors.Is(err, usecase.ErrUnknownEventType) {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, sub)
+		})
+
+		r.Delete("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("REQUEST: DELETE /admin/webhooks/%d", id)
+
+			ok, err := uc.Unsubscribe(r.Context(), id)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "Subscription not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		r.Get("/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			log.Printf("REQUEST: GET /admin/webhooks/%d/deliveries", id)
+
+			list, err := uc.Deliveries(r.Context(), id, limit)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, list)
+		})
+
+		r.Post("/deliveries/{id}/replay", func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				http.Error(w, "bad id", http.StatusBadRequest)
+				return
+			}
+			log.Printf("REQUEST: POST /admin/webhooks/deliveries/%d/replay", id)
+
+			ok, err := uc.Replay(r.Context(), id)
+			if err != nil {
+				log.Printf("INTERNAL ERROR: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "Delivery not found", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Printf("ENCODE ERROR: %v", err)
+		http.Error(w, "encode error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(pretty)
+}
@@ -2,72 +2,409 @@ package kafkaC
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/segmentio/kafka-go"
+	"hash/fnv"
 	"log"
-	"order-service/internal/domain"
+	"order-service/internal/kafkaconn"
+	"order-service/internal/metrics"
 	"order-service/internal/usecase"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
-func Start(
-	ctx context.Context,
-	uc *usecase.OrderUC,
-	brokers []string,
-	topic string,
-	groupID string,
-) error {
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:        brokers,
-		GroupID:        groupID,
-		Topic:          topic,
-		StartOffset:    kafka.LastOffset,
-		CommitInterval: 0,
-		MinBytes:       1_000,
-		MaxBytes:       1_000_000,
-		MaxWait:        500 * time.Millisecond,
-	})
+const (
+	contentTypeHeader = "content-type"
+
+	retryBackoff = 200 * time.Millisecond
+)
+
+type Config struct {
+	Conn    *kafkaconn.Conn
+	GroupID string
+
+	// Offsets are committed every CommitInterval or as soon as CommitBatch
+	// messages completed, whichever comes first.
+	CommitInterval time.Duration
+	CommitBatch    int
+
+	// ParkingTopic receives messages of unknown schema versions or event
+	// types and failures of routes with the Park policy untouched, empty drops them.
+	ParkingTopic string
+
+	// Gate pauses all routes, nil never pauses.
+	Gate *Gate
+	// Lag follows the assigned partitions of all routes, nil disables it.
+	Lag *LagMonitor
+}
+
+// consumer reads the topic of one route, each route has its own reader and workers.
+type consumer struct {
+	r       *kafka.Reader
+	cfg     Config
+	route   Route
+	offsets *offsetTracker
+	parking *kafka.Writer
+	gate    *Gate
+	lag     *LagMonitor
+
+	// stop is done once fetching stopped, messages waiting for the gate give up then
+	stop      context.Context
+	completed chan struct{}
+}
+
+// Start consumes all routes until ctx is done or one of them fails.
+func Start(ctx context.Context, cfg Config, routes ...Route) error {
+	if cfg.CommitBatch < 1 {
+		cfg.CommitBatch = 1
+	}
+	if cfg.CommitInterval <= 0 {
+		cfg.CommitInterval = time.Second
+	}
 
-	errCh := make(chan error, 1)
+	var parking *kafka.Writer
+	if cfg.ParkingTopic != "" {
+		parking = cfg.Conn.Writer(cfg.ParkingTopic)
+		defer parking.Close()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if cfg.Gate != nil {
+		go cfg.Gate.Run(ctx)
+	}
+	if cfg.Lag != nil {
+		// the lag monitor finds our group members by the client id
+		clientID := memberClientID()
+		conn, dialer := *cfg.Conn, *cfg.Conn.Dialer
+		dialer.ClientID = clientID
+		conn.Dialer = &dialer
+		cfg.Conn = &conn
+		go cfg.Lag.Run(ctx, cfg.Conn.Client(), cfg.GroupID, clientID)
+	}
+
+	errs := make(chan error, len(routes))
+	for _, route := range routes {
+		c := newConsumer(cfg, route.withDefaults(), parking)
+		go func() {
+			err := c.run(ctx)
+			cancel() // one broken route stops the others
+			errs <- err
+		}()
+	}
+
+	var first error
+	for range routes {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func newConsumer(cfg Config, route Route, parking *kafka.Writer) *consumer {
+	return &consumer{
+		r: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:        cfg.Conn.Brokers,
+			Dialer:         cfg.Conn.Dialer,
+			GroupID:        cfg.GroupID,
+			Topic:          route.Topic,
+			StartOffset:    kafka.LastOffset,
+			CommitInterval: 0,
+			MinBytes:       1_000,
+			MaxBytes:       1_000_000,
+			MaxWait:        500 * time.Millisecond,
+		}),
+		cfg:       cfg,
+		route:     route,
+		offsets:   newOffsetTracker(),
+		parking:   parking,
+		gate:      cfg.Gate,
+		lag:       cfg.Lag,
+		completed: make(chan struct{}, cfg.CommitBatch),
+	}
+}
+
+func (c *consumer) run(ctx context.Context) error {
+	defer c.r.Close()
+	c.stop = ctx
+
+	log.Printf("kafka consumer: %s (%s), %d workers, on error %s", c.route.Topic, c.cfg.GroupID, c.route.Workers, c.route.OnError)
+
+	queues := make([]chan kafka.Message, c.route.Workers)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, c.route.QueueSize)
+		workers.Add(1)
+		go func(q <-chan kafka.Message) {
+			defer workers.Done()
+			c.work(q)
+		}(queues[i])
+	}
+
+	commitDone := make(chan struct{})
+	commitCtx, stopCommit := context.WithCancel(context.Background())
 	go func() {
-		defer r.Close()
-		defer close(errCh)
-
-		for {
-			msg, err := r.ReadMessage(ctx)
-			if err != nil {
-				if ctx.Err() != nil {
-					return
+		defer close(commitDone)
+		c.commitLoop(commitCtx)
+	}()
+
+	err := c.fetch(ctx, queues)
+
+	// drain: let workers finish what was fetched, then commit it
+	for _, q := range queues {
+		close(q)
+	}
+	workers.Wait()
+	stopCommit()
+	<-commitDone
+	c.commit(context.Background())
+
+	return err
+}
+
+func (c *consumer) fetch(ctx context.Context, queues []chan kafka.Message) error {
+	for {
+		if err := c.gate.Wait(ctx); err != nil {
+			return err
+		}
+		msg, err := c.r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("kafka read %s: %v", c.route.Topic, err)
+			return fmt.Errorf("kafka read error: %w", err)
+		}
+
+		c.offsets.track(msg)
+		c.lag.fetched(msg)
+		q := queues[worker(msg, len(queues))]
+		select {
+		case q <- msg:
+		case <-ctx.Done():
+			// never dispatched and never committed, it is fetched again after restart
+			return ctx.Err()
+		}
+	}
+}
+
+// memberClientID names the readers of this process, unique within the group.
+func memberClientID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("order-service-%s-%d", host, os.Getpid())
+}
+
+func worker(msg kafka.Message, n int) int {
+	h := fnv.New32a()
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		fmt.Fprintf(h, "%s/%d", msg.Topic, msg.Partition)
+	}
+	return int(h.Sum32() % uint32(n))
+}
+
+// work accumulates messages of one worker queue into batches.
+func (c *consumer) work(q <-chan kafka.Message) {
+	batch := make([]kafka.Message, 0, c.route.BatchSize)
+	window := time.NewTimer(c.route.BatchWindow)
+	window.Stop()
+
+	flush := func() {
+		c.route.Handler.handle(context.Background(), c, batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case msg, ok := <-q:
+			if !ok {
+				if len(batch) > 0 {
+					flush()
 				}
-				log.Printf("kafka read: %v", err)
-				errCh <- fmt.Errorf("kafka read error: %w", err)
 				return
 			}
-
-			var ord domain.Order
-			if err := json.Unmarshal(msg.Value, &ord); err != nil {
-				log.Printf("bad JSON, skip: %v", err)
-				_ = r.CommitMessages(ctx, msg) // ack «мусор»
-				continue
+			if len(batch) == 0 {
+				window.Reset(c.route.BatchWindow)
 			}
-
-			if err := uc.Set(context.Background(), &ord); err != nil {
-				fmt.Printf("save error: %v\n", err)
-				log.Printf("save failed (retry later): %v", err)
-				continue
+			batch = append(batch, msg)
+			if len(batch) >= c.route.BatchSize {
+				window.Stop()
+				flush()
+			}
+		case <-window.C:
+			if len(batch) > 0 {
+				flush()
 			}
+		}
+	}
+}
 
-			if err := r.CommitMessages(ctx, msg); err != nil {
-				log.Printf("commit offset: %v", err)
+// apply runs fn for one message up to route.Attempts times, then hands the
+// last error to the error policy of the route. While the gate is closed the
+// message waits for it instead of spending attempts. It reports false when
+// the consumer stopped during the wait, the message is left uncommitted then.
+func (c *consumer) apply(ctx context.Context, msg kafka.Message, fn func(context.Context) error) bool {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		c.observe(err)
+		if err == nil {
+			c.count("handled", 1)
+			c.finish(msg)
+			return true
+		}
+		if c.gate.Paused() {
+			log.Printf("%s/%d@%d failed, waiting for the consumer to resume: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			if c.gate.Wait(c.stop) != nil {
+				return false
 			}
+			attempt--
+			continue
 		}
-	}()
+		if attempt >= c.route.Attempts {
+			log.Printf("%s/%d@%d failed after %d attempts: %v", msg.Topic, msg.Partition, msg.Offset, attempt, err)
+			c.fail(msg, err)
+			c.finish(msg)
+			return true
+		}
+		log.Printf("%s/%d@%d failed (retry %d): %v", msg.Topic, msg.Partition, msg.Offset, attempt, err)
+		time.Sleep(retryBackoff << (attempt - 1))
+	}
+}
+
+// fail applies the error policy of the route to a message that could not be handled.
+func (c *consumer) fail(msg kafka.Message, err error) {
+	if c.route.OnError == Park {
+		c.park(msg, err)
+		return
+	}
+	log.Printf("skip %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	c.count("skipped", 1)
+}
+
+// park moves a message this build cannot handle to the parking topic,
+// so it can be replayed once a newer consumer knows its version.
+func (c *consumer) park(msg kafka.Message, reason error) {
+	if c.parking == nil {
+		log.Printf("cannot handle %s/%d@%d and no parking topic, skip: %v", msg.Topic, msg.Partition, msg.Offset, reason)
+		c.count("skipped", 1)
+		return
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "parked-reason", Value: []byte(reason.Error())},
+		kafka.Header{Key: "parked-from", Value: []byte(fmt.Sprintf("%s/%d@%d", msg.Topic, msg.Partition, msg.Offset))},
+	)
+
+	err := c.parking.WriteMessages(context.Background(), kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Time:    msg.Time,
+		Headers: headers,
+	})
+	if err != nil {
+		log.Printf("park %s/%d@%d failed: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		c.count("park_failed", 1)
+		return
+	}
+	log.Printf("parked %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, reason)
+	c.count("parked", 1)
+}
 
+// finish marks the message done, its offset is committed with the next round.
+func (c *consumer) finish(msg kafka.Message) {
+	c.offsets.done(msg)
+	c.lag.processed(msg)
 	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-errCh:
-		return err
+	case c.completed <- struct{}{}:
+	default:
+	}
+}
+
+// observe feeds handler results into the error rate of the gate. Only
+// database trouble counts as a failure, a rejected or missing order is a
+// successful call as far as the database health goes.
+func (c *consumer) observe(err error) {
+	if err != nil && !dbTrouble(err) {
+		err = nil
+	}
+	c.gate.Observe(err)
+}
+
+// dbTrouble tells connection and transient database errors from errors in
+// the data. Errors without a Postgres code are network failures, timeouts
+// and the like; of the coded ones only connection exceptions (class 08),
+// exhausted resources (53) and operator intervention such as a shutdown
+// (57) are.
+func dbTrouble(err error) bool {
+	if errors.Is(err, usecase.ErrOrderNotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return true
+	}
+	for _, class := range []string{"08", "53", "57"} {
+		if strings.HasPrefix(pgErr.Code, class) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *consumer) count(result string, n int) {
+	metrics.ConsumerMessages.WithLabelValues(c.route.Topic, result).Add(float64(n))
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c *consumer) commitLoop(ctx context.Context) {
+	t := time.NewTicker(c.cfg.CommitInterval)
+	defer t.Stop()
+
+	pending := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-c.completed:
+			pending++
+			if pending < c.cfg.CommitBatch {
+				continue
+			}
+		}
+		pending = 0
+		c.commit(ctx)
+	}
+}
+
+func (c *consumer) commit(ctx context.Context) {
+	msgs := c.offsets.commitable()
+	if len(msgs) == 0 {
+		return
+	}
+	if err := c.r.CommitMessages(ctx, msgs...); err != nil {
+		log.Printf("commit offset %s: %v", c.route.Topic, err)
+		c.offsets.uncommit(msgs)
+		return
 	}
+	c.lag.committed(msgs)
 }
@@ -0,0 +1,295 @@
+package kafkaC
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"order-service/internal/metrics"
+	"sync"
+	"time"
+)
+
+// GateConfig tunes the automatic pause of the consumer.
+type GateConfig struct {
+	// Check probes the database, it runs every CheckInterval while the
+	// consumer runs and decides when an automatic pause ends.
+	Check         func(ctx context.Context) error
+	CheckInterval time.Duration
+
+	// The consumer pauses when at least MinSamples handler calls happened
+	// within Window and the share of failures reached ErrorRate.
+	ErrorRate  float64
+	MinSamples int
+	Window     time.Duration
+
+	// After an automatic pause the consumer stays paused at least Backoff,
+	// doubled for every pause that follows within Window of the last
+	// resume, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// GateState is the pause state shown by the admin and readiness endpoints.
+type GateState struct {
+	Paused    bool      `json:"paused"`
+	Manual    bool      `json:"manual"`
+	Reason    string    `json:"reason,omitempty"`
+	Since     time.Time `json:"since"`
+	ErrorRate float64   `json:"error_rate"`
+}
+
+// Gate stops all routes from fetching while the database is unhealthy or an
+// operator paused the consumer. Messages already fetched wait for the gate
+// instead of being skipped or parked.
+type Gate struct {
+	cfg  GateConfig
+	kick chan struct{}
+
+	mu      sync.Mutex
+	open    chan struct{} // closed while the consumer runs
+	manual  bool
+	auto    bool
+	reason  string
+	since   time.Time
+	resumed time.Time
+	backoff time.Duration
+
+	windowStart time.Time
+	calls       int
+	failures    int
+}
+
+func NewGate(cfg GateConfig) *Gate {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 5 * time.Second
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 30 * time.Second
+	}
+	if cfg.MinSamples < 1 {
+		cfg.MinSamples = 1
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = cfg.Backoff
+	}
+
+	open := make(chan struct{})
+	close(open)
+	metrics.ConsumerPaused.Set(0)
+	return &Gate{
+		cfg:         cfg,
+		kick:        make(chan struct{}, 1),
+		open:        open,
+		since:       time.Now(),
+		backoff:     cfg.Backoff,
+		windowStart: time.Now(),
+	}
+}
+
+// Wait blocks while the consumer is paused.
+func (g *Gate) Wait(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	open := g.open
+	g.mu.Unlock()
+
+	select {
+	case <-open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *Gate) Paused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.manual || g.auto
+}
+
+// Pause stops the consumer until Resume, automatic recovery does not lift it.
+func (g *Gate) Pause(reason string) {
+	if reason == "" {
+		reason = "manual"
+	}
+	log.Printf("kafka consumer paused by operator: %s", reason)
+	metrics.ConsumerPauses.WithLabelValues("manual").Inc()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.manual = true
+	g.auto = false
+	g.reason = reason
+	g.update()
+}
+
+// Resume lifts manual and automatic pauses at once.
+func (g *Gate) Resume() {
+	log.Println("kafka consumer resumed by operator")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.manual = false
+	g.auto = false
+	g.reason = ""
+	g.backoff = g.cfg.Backoff
+	g.resetWindow()
+	g.update()
+}
+
+func (g *Gate) State() GateState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s := GateState{
+		Paused: g.manual || g.auto,
+		Manual: g.manual,
+		Reason: g.reason,
+		Since:  g.since,
+	}
+	if g.calls > 0 {
+		s.ErrorRate = float64(g.failures) / float64(g.calls)
+	}
+	return s
+}
+
+// Observe feeds the result of a handler call into the error rate.
+func (g *Gate) Observe(err error) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if time.Since(g.windowStart) > g.cfg.Window {
+		g.resetWindow()
+	}
+	g.calls++
+	if err != nil {
+		g.failures++
+	}
+
+	if g.cfg.ErrorRate <= 0 || g.calls < g.cfg.MinSamples || g.manual || g.auto {
+		return
+	}
+	if rate := float64(g.failures) / float64(g.calls); rate >= g.cfg.ErrorRate {
+		g.pauseAuto("error_rate", fmt.Sprintf("error rate %.2f over %d calls", rate, g.calls))
+	}
+}
+
+// Run checks the database until ctx is done and lifts automatic pauses once
+// the backoff passed and the check succeeds.
+func (g *Gate) Run(ctx context.Context) {
+	if g.cfg.Check == nil {
+		return
+	}
+	t := time.NewTimer(g.cfg.CheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.kick:
+			if !t.Stop() {
+				select {
+				case <-t.C:
+				default:
+				}
+			}
+		case <-t.C:
+		}
+
+		t.Reset(g.probe(ctx))
+	}
+}
+
+// probe runs one check and returns the delay before the next one.
+func (g *Gate) probe(ctx context.Context) time.Duration {
+	g.mu.Lock()
+	wait := g.backoff - time.Since(g.since)
+	paused := g.auto
+	g.mu.Unlock()
+
+	if paused && wait > 0 {
+		return wait
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, g.cfg.CheckInterval)
+	err := g.cfg.Check(checkCtx)
+	cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case err != nil && !g.auto && !g.manual:
+		g.pauseAuto("health", "database: "+err.Error())
+		return g.backoff
+	case err != nil && g.auto:
+		log.Printf("kafka consumer stays paused, database: %v", err)
+		return g.backoff
+	case err == nil && g.auto:
+		log.Printf("kafka consumer resumed after %s: %s", time.Since(g.since).Round(time.Second), g.reason)
+		g.auto = false
+		g.reason = ""
+		g.resumed = time.Now()
+		g.resetWindow()
+		g.update()
+	}
+	return g.cfg.CheckInterval
+}
+
+// pauseAuto must be called with g.mu held.
+func (g *Gate) pauseAuto(cause, reason string) {
+	if !g.resumed.IsZero() && time.Since(g.resumed) < g.cfg.Window {
+		g.backoff = min(g.backoff*2, g.cfg.MaxBackoff)
+	} else {
+		g.backoff = g.cfg.Backoff
+	}
+	log.Printf("kafka consumer paused for at least %s: %s", g.backoff, reason)
+	metrics.ConsumerPauses.WithLabelValues(cause).Inc()
+
+	g.auto = true
+	g.reason = reason
+	g.update()
+
+	select {
+	case g.kick <- struct{}{}:
+	default:
+	}
+}
+
+// update opens or closes the gate after a state change, g.mu must be held.
+func (g *Gate) update() {
+	paused := g.manual || g.auto
+	select {
+	case <-g.open:
+		if paused {
+			g.open = make(chan struct{})
+			g.since = time.Now()
+			metrics.ConsumerPaused.Set(1)
+		}
+	default:
+		if !paused {
+			close(g.open)
+			g.since = time.Now()
+			metrics.ConsumerPaused.Set(0)
+		}
+	}
+}
+
+// resetWindow must be called with g.mu held.
+func (g *Gate) resetWindow() {
+	g.windowStart = time.Now()
+	g.calls = 0
+	g.failures = 0
+}
@@ -0,0 +1,358 @@
+package kafkaC
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"order-service/internal/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LagConfig tunes the lag monitor.
+type LagConfig struct {
+	// Interval between refreshes of assignments and high watermarks,
+	// fetched messages update them in between, but an idle or paused
+	// consumer fetches nothing.
+	Interval time.Duration
+
+	// The instance is degraded once the total lag stayed above Threshold
+	// for For, zero Threshold never degrades it.
+	Threshold int64
+	For       time.Duration
+}
+
+// PartitionLag is the position of the consumer in one assigned partition.
+// Committed is the next offset to read after a restart, -1 while unknown.
+type PartitionLag struct {
+	Topic         string     `json:"topic"`
+	Partition     int        `json:"partition"`
+	Committed     int64      `json:"committed_offset"`
+	HighWatermark int64      `json:"high_watermark"`
+	Lag           int64      `json:"lag"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+}
+
+// LagState is what the admin and readiness endpoints show.
+type LagState struct {
+	Total      int64          `json:"total_lag"`
+	Threshold  int64          `json:"threshold"`
+	Degraded   bool           `json:"degraded"`
+	HighSince  *time.Time     `json:"high_since,omitempty"`
+	Partitions []PartitionLag `json:"partitions,omitempty"`
+}
+
+// LagMonitor follows the partitions assigned to this instance across
+// rebalances. kafka-go reports no assignments, so every refresh reads them
+// from the group members with our client id, a partition we fetch from is
+// assigned right away.
+type LagMonitor struct {
+	cfg LagConfig
+
+	mu        sync.Mutex
+	parts     map[partitionKey]*PartitionLag
+	highSince time.Time
+	degraded  bool
+}
+
+func NewLagMonitor(cfg LagConfig) *LagMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Second
+	}
+	return &LagMonitor{cfg: cfg, parts: make(map[partitionKey]*PartitionLag)}
+}
+
+func (m *LagMonitor) fetched(msg kafka.Message) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := m.track(partitionKey{msg.Topic, msg.Partition})
+	if msg.HighWaterMark > p.HighWatermark {
+		p.HighWatermark = msg.HighWaterMark
+	}
+	if p.Committed < 0 {
+		// nothing committed yet, count from the first fetched message
+		p.Committed = msg.Offset
+	}
+	m.update(p)
+}
+
+func (m *LagMonitor) processed(msg kafka.Message) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.parts[partitionKey{msg.Topic, msg.Partition}]
+	if !ok {
+		// revoked while the message was processed
+		return
+	}
+	if p.LastMessageAt == nil || msg.Time.After(*p.LastMessageAt) {
+		at := msg.Time
+		p.LastMessageAt = &at
+		metrics.ConsumerLastMessage.WithLabelValues(p.Topic, strconv.Itoa(p.Partition)).Set(float64(msg.Time.Unix()))
+	}
+}
+
+func (m *LagMonitor) committed(msgs []kafka.Message) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, msg := range msgs {
+		if p, ok := m.parts[partitionKey{msg.Topic, msg.Partition}]; ok {
+			p.Committed = msg.Offset + 1
+			m.update(p)
+		}
+	}
+}
+
+// Run refreshes the partitions assigned to the members of group with
+// clientID, their committed offsets and high watermarks, and evaluates the
+// degraded state until ctx is done.
+func (m *LagMonitor) Run(ctx context.Context, client *kafka.Client, group, clientID string) {
+	t := time.NewTicker(m.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := m.refresh(ctx, client, group, clientID); err != nil && ctx.Err() == nil {
+			log.Printf("kafka lag refresh: %v", err)
+		}
+		m.evaluate()
+	}
+}
+
+func (m *LagMonitor) refresh(ctx context.Context, client *kafka.Client, group, clientID string) error {
+	groups, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{group}})
+	if err != nil {
+		return err
+	}
+	for _, g := range groups.Groups {
+		if g.Error != nil {
+			return g.Error
+		}
+		// assignments are settled once the group is stable again
+		if g.GroupState == "Stable" || g.GroupState == "Empty" {
+			m.assign(g.Members, clientID)
+		}
+	}
+
+	m.mu.Lock()
+	partitions := make(map[string][]int)
+	for key := range m.parts {
+		partitions[key.topic] = append(partitions[key.topic], key.partition)
+	}
+	m.mu.Unlock()
+
+	if len(partitions) == 0 {
+		return nil
+	}
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group, Topics: partitions})
+	if err != nil {
+		return err
+	}
+	if committed.Error != nil {
+		return committed.Error
+	}
+	m.groupCommitted(committed.Topics)
+
+	m.mu.Lock()
+	topics := make(map[string][]kafka.OffsetRequest)
+	for key := range m.parts {
+		topics[key.topic] = append(topics[key.topic], kafka.LastOffsetOf(key.partition))
+	}
+	m.mu.Unlock()
+
+	if len(topics) == 0 {
+		return nil
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: topics})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for topic, parts := range resp.Topics {
+		for _, po := range parts {
+			if po.Error != nil {
+				log.Printf("kafka lag refresh %s/%d: %v", topic, po.Partition, po.Error)
+				continue
+			}
+			// a partition revoked while the request was in flight is not tracked again
+			if p, ok := m.parts[partitionKey{topic, po.Partition}]; ok {
+				p.HighWatermark = po.LastOffset
+				m.update(p)
+			}
+		}
+	}
+	return nil
+}
+
+// assign tracks the partitions of the members with clientID, one for each
+// topic we read, and drops the ones no longer assigned to them.
+func (m *LagMonitor) assign(members []kafka.DescribeGroupsResponseMember, clientID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	assigned := make(map[partitionKey]bool)
+	for _, member := range members {
+		if member.ClientID != clientID {
+			continue
+		}
+		for _, t := range member.MemberAssignments.Topics {
+			for _, partition := range t.Partitions {
+				assigned[partitionKey{t.Topic, partition}] = true
+			}
+		}
+	}
+
+	for key := range m.parts {
+		if !assigned[key] {
+			log.Printf("kafka %s rebalance: partition %d revoked", key.topic, key.partition)
+			delete(m.parts, key)
+			m.deleteMetrics(key)
+		}
+	}
+	for key := range assigned {
+		m.update(m.track(key))
+	}
+}
+
+// groupCommitted takes the offsets the group committed, our own commits may
+// still be in flight, so a lower offset is ignored.
+func (m *LagMonitor) groupCommitted(topics map[string][]kafka.OffsetFetchPartition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for topic, parts := range topics {
+		for _, po := range parts {
+			if po.Error != nil {
+				log.Printf("kafka lag refresh %s/%d: %v", topic, po.Partition, po.Error)
+				continue
+			}
+			p, ok := m.parts[partitionKey{topic, po.Partition}]
+			if !ok || po.CommittedOffset < 0 {
+				continue
+			}
+			if po.CommittedOffset > p.Committed {
+				p.Committed = po.CommittedOffset
+				m.update(p)
+			}
+		}
+	}
+}
+
+func (m *LagMonitor) evaluate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cfg.Threshold <= 0 {
+		return
+	}
+	total := m.total()
+	switch {
+	case total <= m.cfg.Threshold:
+		if m.degraded {
+			log.Printf("kafka lag %d back under %d, instance healthy again", total, m.cfg.Threshold)
+		}
+		m.highSince = time.Time{}
+		m.degraded = false
+	case m.highSince.IsZero():
+		m.highSince = time.Now()
+	case !m.degraded && time.Since(m.highSince) >= m.cfg.For:
+		log.Printf("kafka lag %d above %d for %s, instance degraded", total, m.cfg.Threshold, time.Since(m.highSince).Round(time.Second))
+		m.degraded = true
+	}
+	metrics.ConsumerDegraded.Set(boolGauge(m.degraded))
+}
+
+func (m *LagMonitor) State() LagState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := LagState{
+		Total:      m.total(),
+		Threshold:  m.cfg.Threshold,
+		Degraded:   m.degraded,
+		Partitions: make([]PartitionLag, 0, len(m.parts)),
+	}
+	if !m.highSince.IsZero() {
+		since := m.highSince
+		s.HighSince = &since
+	}
+	for _, p := range m.parts {
+		s.Partitions = append(s.Partitions, *p)
+	}
+	sort.Slice(s.Partitions, func(i, j int) bool {
+		a, b := s.Partitions[i], s.Partitions[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	return s
+}
+
+// total must be called with m.mu held.
+func (m *LagMonitor) total() int64 {
+	var total int64
+	for _, p := range m.parts {
+		total += p.Lag
+	}
+	return total
+}
+
+// track returns the partition, logging its assignment when it is new. It
+// must be called with m.mu held.
+func (m *LagMonitor) track(key partitionKey) *PartitionLag {
+	p, ok := m.parts[key]
+	if !ok {
+		log.Printf("kafka %s rebalance: partition %d assigned", key.topic, key.partition)
+		p = &PartitionLag{Topic: key.topic, Partition: key.partition, Committed: -1}
+		m.parts[key] = p
+	}
+	return p
+}
+
+// update recomputes the lag of p and exports it, m.mu must be held.
+func (m *LagMonitor) update(p *PartitionLag) {
+	p.Lag = 0
+	if p.Committed >= 0 && p.HighWatermark > p.Committed {
+		p.Lag = p.HighWatermark - p.Committed
+	}
+
+	partition := strconv.Itoa(p.Partition)
+	metrics.ConsumerCommittedOffset.WithLabelValues(p.Topic, partition).Set(float64(p.Committed))
+	metrics.ConsumerHighWatermark.WithLabelValues(p.Topic, partition).Set(float64(p.HighWatermark))
+	metrics.ConsumerLag.WithLabelValues(p.Topic, partition).Set(float64(p.Lag))
+}
+
+func (m *LagMonitor) deleteMetrics(key partitionKey) {
+	partition := strconv.Itoa(key.partition)
+	metrics.ConsumerCommittedOffset.DeleteLabelValues(key.topic, partition)
+	metrics.ConsumerHighWatermark.DeleteLabelValues(key.topic, partition)
+	metrics.ConsumerLag.DeleteLabelValues(key.topic, partition)
+	metrics.ConsumerLastMessage.DeleteLabelValues(key.topic, partition)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package kafkaC
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol/describegroups"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/offsetfetch"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeBroker answers the group and offset requests of the lag monitor for
+// the orders topic.
+type fakeBroker struct {
+	t         *testing.T
+	group     string
+	state     string
+	members   map[string][]int // partitions by client id
+	committed map[int]int64
+	high      map[int]int64
+}
+
+// assignment encodes partitions of orders in the consumer protocol.
+func assignment(partitions []int) []byte {
+	b := binary.BigEndian.AppendUint16(nil, 0)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint16(b, uint16(len("orders")))
+	b = append(b, "orders"...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(partitions)))
+	for _, p := range partitions {
+		b = binary.BigEndian.AppendUint32(b, uint32(p))
+	}
+	// no user data
+	return binary.BigEndian.AppendUint32(b, 0)
+}
+
+func (b *fakeBroker) RoundTrip(_ context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	switch req := req.(type) {
+	case *describegroups.Request:
+		g := describegroups.ResponseGroup{GroupID: b.group, GroupState: b.state}
+		for clientID, partitions := range b.members {
+			g.Members = append(g.Members, describegroups.ResponseGroupMember{
+				MemberID:         clientID + "-member",
+				ClientID:         clientID,
+				MemberAssignment: assignment(partitions),
+			})
+		}
+		return &describegroups.Response{Groups: []describegroups.ResponseGroup{g}}, nil
+	case *offsetfetch.Request:
+		if req.GroupID != b.group {
+			b.t.Errorf("offsets fetched for group %q, want %q", req.GroupID, b.group)
+		}
+		res := &offsetfetch.Response{}
+		for _, topic := range req.Topics {
+			rt := offsetfetch.ResponseTopic{Name: topic.Name}
+			for _, p := range topic.PartitionIndexes {
+				offset, ok := b.committed[int(p)]
+				if !ok {
+					offset = -1
+				}
+				rt.Partitions = append(rt.Partitions, offsetfetch.ResponsePartition{PartitionIndex: p, CommittedOffset: offset})
+			}
+			res.Topics = append(res.Topics, rt)
+		}
+		return res, nil
+	case *listoffsets.Request:
+		res := &listoffsets.Response{}
+		for _, topic := range req.Topics {
+			rt := listoffsets.ResponseTopic{Topic: topic.Topic}
+			for _, p := range topic.Partitions {
+				rt.Partitions = append(rt.Partitions, listoffsets.ResponsePartition{
+					Partition: p.Partition,
+					Timestamp: p.Timestamp,
+					Offset:    b.high[int(p.Partition)],
+				})
+			}
+			res.Topics = append(res.Topics, rt)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("unexpected request %T", req)
+}
+
+// captureLog collects the standard logger output of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func samePartitions(t *testing.T, got, want []PartitionLag) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got partitions %+v, want %+v", got, want)
+	}
+	for i, p := range got {
+		w := want[i]
+		if p.Topic != w.Topic || p.Partition != w.Partition || p.Committed != w.Committed || p.HighWatermark != w.HighWatermark || p.Lag != w.Lag {
+			t.Errorf("got partition %+v, want %+v", p, w)
+		}
+	}
+}
+
+func TestLagMonitorRefresh(t *testing.T) {
+	logs := captureLog(t)
+	m := NewLagMonitor(LagConfig{Threshold: 5})
+	// owned before the rebalance
+	m.fetched(kafka.Message{Topic: "orders", Partition: 0, Offset: 9, HighWaterMark: 15})
+	m.fetched(kafka.Message{Topic: "orders", Partition: 1, Offset: 3, HighWaterMark: 8})
+	m.committed([]kafka.Message{{Topic: "orders", Partition: 0, Offset: 9}})
+
+	broker := &fakeBroker{
+		t:       t,
+		group:   "order-service",
+		state:   "Stable",
+		members: map[string][]int{"me": {0, 2}, "other": {1}},
+		// partition 0: our commit is not visible yet
+		committed: map[int]int64{0: 7, 1: 6, 2: 1},
+		high:      map[int]int64{0: 20, 1: 8, 2: 4},
+	}
+	client := &kafka.Client{Addr: kafka.TCP("kafka:9092"), Transport: broker}
+	refresh := func() {
+		t.Helper()
+		if err := m.refresh(context.Background(), client, broker.group, "me"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refresh()
+	s := m.State()
+	samePartitions(t, s.Partitions, []PartitionLag{
+		{Topic: "orders", Partition: 0, Committed: 10, HighWatermark: 20, Lag: 10},
+		{Topic: "orders", Partition: 2, Committed: 1, HighWatermark: 4, Lag: 3},
+	})
+	if s.Total != 13 {
+		t.Errorf("total lag %d, want 13", s.Total)
+	}
+	for _, line := range []string{"orders rebalance: partition 0 assigned", "orders rebalance: partition 1 assigned",
+		"orders rebalance: partition 1 revoked", "orders rebalance: partition 2 assigned"} {
+		if !strings.Contains(logs.String(), line) {
+			t.Errorf("log has no %q:\n%s", line, logs)
+		}
+	}
+
+	// nothing changes while the group rebalances
+	broker.state, broker.members = "PreparingRebalance", nil
+	refresh()
+	if s := m.State(); len(s.Partitions) != 2 {
+		t.Errorf("partitions changed during the rebalance: %+v", s.Partitions)
+	}
+
+	// an idle partition is revoked as well
+	broker.state, broker.members = "Stable", map[string][]int{"me": {2}, "other": {0, 1}}
+	logs.Reset()
+	refresh()
+	samePartitions(t, m.State().Partitions, []PartitionLag{
+		{Topic: "orders", Partition: 2, Committed: 1, HighWatermark: 4, Lag: 3},
+	})
+	if !strings.Contains(logs.String(), "orders rebalance: partition 0 revoked") {
+		t.Errorf("revocation of partition 0 was not logged:\n%s", logs)
+	}
+
+	// a message processed after the revocation does not bring it back
+	m.processed(kafka.Message{Topic: "orders", Partition: 0, Offset: 10})
+	m.committed([]kafka.Message{{Topic: "orders", Partition: 0, Offset: 10}})
+	if s := m.State(); len(s.Partitions) != 1 {
+		t.Errorf("revoked partition is tracked again: %+v", s.Partitions)
+	}
+}
+
+func TestLagMonitorDegraded(t *testing.T) {
+	m := NewLagMonitor(LagConfig{Threshold: 5})
+	m.fetched(kafka.Message{Topic: "orders", Partition: 0, Offset: 0, HighWaterMark: 10})
+
+	m.evaluate()
+	if s := m.State(); s.Degraded || s.HighSince == nil {
+		t.Fatalf("got %+v, want high but not degraded yet", s)
+	}
+	m.evaluate()
+	if !m.State().Degraded {
+		t.Fatal("not degraded after the lag stayed high")
+	}
+
+	m.committed([]kafka.Message{{Topic: "orders", Partition: 0, Offset: 8}})
+	m.evaluate()
+	if s := m.State(); s.Degraded || s.HighSince != nil {
+		t.Errorf("got %+v, want healthy again", s)
+	}
+}
@@ -0,0 +1,101 @@
+package kafkaC
+
+import (
+	"github.com/segmentio/kafka-go"
+	"sync"
+)
+
+// offsetTracker remembers fetched messages per partition in fetch order and
+// finds the last message of the completed prefix, which is the only offset
+// that is safe to commit while later messages are still in flight.
+// Fetch order is used instead of offset arithmetic because compacted or
+// transactional topics have gaps between offsets.
+type offsetTracker struct {
+	mu    sync.Mutex
+	parts map[partitionKey]*partitionOffsets
+}
+
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	pending   []kafka.Message
+	done      map[int64]struct{}
+	committed int64
+	ready     *kafka.Message
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{parts: make(map[partitionKey]*partitionOffsets)}
+}
+
+func (t *offsetTracker) track(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partition(msg)
+	p.pending = append(p.pending, msg)
+}
+
+func (t *offsetTracker) done(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.partition(msg)
+	p.done[msg.Offset] = struct{}{}
+
+	for len(p.pending) > 0 {
+		head := p.pending[0]
+		if _, ok := p.done[head.Offset]; !ok {
+			break
+		}
+		delete(p.done, head.Offset)
+		p.pending = p.pending[1:]
+		p.ready = &head
+	}
+}
+
+// commitable returns one message per partition whose offset moved since the last call.
+func (t *offsetTracker) commitable() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msgs := make([]kafka.Message, 0, len(t.parts))
+	for _, p := range t.parts {
+		if p.ready == nil || p.ready.Offset <= p.committed {
+			continue
+		}
+		msgs = append(msgs, *p.ready)
+		p.committed = p.ready.Offset
+		p.ready = nil
+	}
+	return msgs
+}
+
+// uncommit rolls back a failed commit so the next round retries it.
+func (t *offsetTracker) uncommit(msgs []kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, msg := range msgs {
+		p := t.partition(msg)
+		if p.ready == nil || p.ready.Offset < msg.Offset {
+			m := msg
+			p.ready = &m
+		}
+		p.committed = msg.Offset - 1
+	}
+}
+
+// partition must be called with t.mu held.
+func (t *offsetTracker) partition(msg kafka.Message) *partitionOffsets {
+	key := partitionKey{msg.Topic, msg.Partition}
+	p, ok := t.parts[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]struct{}), committed: -1}
+		t.parts[key] = p
+	}
+	return p
+}
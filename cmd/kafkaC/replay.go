@@ -0,0 +1,171 @@
+package kafkaC
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"order-service/internal/codec"
+	"order-service/internal/kafkaconn"
+	"order-service/internal/usecase"
+	"time"
+)
+
+type ReplayConfig struct {
+	Conn  *kafkaconn.Conn
+	Topic string
+	// Partitions to replay, empty means all of them.
+	Partitions []int
+
+	// The range starts at FromTime when set, otherwise at FromOffset
+	// (kafka.FirstOffset for the beginning).
+	FromOffset int64
+	FromTime   time.Time
+	// The range ends before ToOffset / after ToTime when set, otherwise at
+	// the high watermark seen when the replay started.
+	ToOffset int64
+	ToTime   time.Time
+
+	DryRun   bool
+	Decoders *codec.Decoders
+}
+
+type ReplayStats struct {
+	Read      int                     `json:"read"`
+	Created   int                     `json:"created"`
+	Changed   int                     `json:"changed"`
+	Unchanged int                     `json:"unchanged"`
+	Failed    int                     `json:"failed"`
+	Results   []*usecase.ReplayResult `json:"results"`
+}
+
+// Replay re-reads a range of the topic and passes it through the normal
+// decode and save path. Partitions are read directly instead of through a
+// consumer group, so the live group's offsets stay untouched and nothing is
+// left behind on the brokers.
+func Replay(ctx context.Context, uc *usecase.OrderUC, cfg ReplayConfig) (*ReplayStats, error) {
+	partitions, err := replayPartitions(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ReplayStats{}
+	for _, p := range partitions {
+		if err := replayPartition(ctx, uc, cfg, p, stats); err != nil {
+			return stats, fmt.Errorf("partition %d: %w", p, err)
+		}
+	}
+	return stats, nil
+}
+
+func replayPartitions(ctx context.Context, cfg ReplayConfig) ([]int, error) {
+	if len(cfg.Partitions) > 0 {
+		return cfg.Partitions, nil
+	}
+
+	conn, err := cfg.Conn.Dialer.DialContext(ctx, "tcp", cfg.Conn.Brokers[0])
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	list, err := conn.ReadPartitions(cfg.Topic)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(list))
+	for _, p := range list {
+		ids = append(ids, p.ID)
+	}
+	return ids, nil
+}
+
+func replayPartition(ctx context.Context, uc *usecase.OrderUC, cfg ReplayConfig, partition int, stats *ReplayStats) error {
+	leader, err := cfg.Conn.Dialer.DialLeader(ctx, "tcp", cfg.Conn.Brokers[0], cfg.Topic, partition)
+	if err != nil {
+		return err
+	}
+	first, last, err := leader.ReadOffsets()
+	leader.Close()
+	if err != nil {
+		return err
+	}
+
+	end := last
+	if cfg.ToOffset > 0 && cfg.ToOffset < end {
+		end = cfg.ToOffset
+	}
+	if end <= first {
+		return nil // nothing in range, and reading would block for new messages
+	}
+
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   cfg.Conn.Brokers,
+		Dialer:    cfg.Conn.Dialer,
+		Topic:     cfg.Topic,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10_000_000,
+		MaxWait:   500 * time.Millisecond,
+	})
+	defer r.Close()
+
+	switch {
+	case !cfg.FromTime.IsZero():
+		err = r.SetOffsetAt(ctx, cfg.FromTime)
+	case cfg.FromOffset > first:
+		err = r.SetOffset(cfg.FromOffset)
+	default:
+		err = r.SetOffset(kafka.FirstOffset)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("replay %s/%d up to offset %d, dry-run=%t", cfg.Topic, partition, end, cfg.DryRun)
+
+	decode := Orders(cfg.Decoders)
+	for {
+		if r.Offset() >= end {
+			return nil
+		}
+
+		msg, err := r.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			return fmt.Errorf("read: %w", err)
+		}
+		if msg.Offset >= end || (!cfg.ToTime.IsZero() && msg.Time.After(cfg.ToTime)) {
+			return nil
+		}
+		stats.Read++
+
+		ord, err := decode(ctx, msg)
+		if err != nil {
+			log.Printf("replay %d@%d: bad payload: %v", partition, msg.Offset, err)
+			stats.Failed++
+			continue
+		}
+
+		res, err := uc.Replay(ctx, ord, cfg.DryRun)
+		if err != nil {
+			log.Printf("replay %d@%d: %s: %v", partition, msg.Offset, ord.OrderId, err)
+			stats.Failed++
+			continue
+		}
+
+		switch {
+		case res.Created:
+			stats.Created++
+		case len(res.Changes) > 0:
+			stats.Changed++
+		default:
+			stats.Unchanged++
+			continue
+		}
+		stats.Results = append(stats.Results, res)
+	}
+}
@@ -0,0 +1,184 @@
+package kafkaC
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"order-service/internal/codec"
+	"order-service/internal/domain"
+	"order-service/internal/metrics"
+	"strings"
+	"time"
+)
+
+// ErrorPolicy decides what happens to a message whose payload is broken or
+// whose handler still fails after all attempts. The offset is committed either way.
+type ErrorPolicy string
+
+const (
+	Skip ErrorPolicy = "skip"
+	Park ErrorPolicy = "park"
+)
+
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch p := ErrorPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case Skip, Park:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown error policy %q, want skip or park", s)
+}
+
+// Route binds a topic to its handler.
+type Route struct {
+	Topic string
+
+	// Workers process messages in parallel, messages with the same key
+	// always go to the same worker and keep their order.
+	Workers   int
+	QueueSize int
+
+	// Each worker hands up to BatchSize messages to the handler at once,
+	// waiting at most BatchWindow for the batch to fill.
+	BatchSize   int
+	BatchWindow time.Duration
+
+	// Attempts is how many times the handler is tried per message before
+	// OnError applies.
+	Attempts int
+	OnError  ErrorPolicy
+
+	Handler Handler
+}
+
+func (r Route) withDefaults() Route {
+	if r.Workers < 1 {
+		r.Workers = 1
+	}
+	if r.BatchSize < 1 {
+		r.BatchSize = 1
+	}
+	if r.Attempts < 1 {
+		r.Attempts = 1
+	}
+	if r.OnError == "" {
+		r.OnError = Skip
+	}
+	return r
+}
+
+// Decode turns a message into the value its handler takes.
+type Decode[T any] func(ctx context.Context, msg kafka.Message) (T, error)
+
+// Orders decodes order messages with the decoder picked by the content-type header.
+func Orders(d *codec.Decoders) Decode[*domain.Order] {
+	return func(ctx context.Context, msg kafka.Message) (*domain.Order, error) {
+		dec, err := d.For(header(msg, contentTypeHeader))
+		if err != nil {
+			return nil, err
+		}
+		return dec.Decode(ctx, msg.Value)
+	}
+}
+
+// JSON decodes plain JSON events, see codec.DecodeEvent.
+func JSON[T any]() Decode[T] {
+	return func(_ context.Context, msg kafka.Message) (T, error) {
+		return codec.DecodeEvent[T](msg.Value)
+	}
+}
+
+// Handler processes the messages of a route and marks every message it is
+// done with, messages left unmarked are fetched again after a restart.
+// It is built from a usecase method with Each or Batched.
+type Handler interface {
+	handle(ctx context.Context, c *consumer, msgs []kafka.Message)
+}
+
+// Each calls fn for every message.
+func Each[T any](decode Decode[T], fn func(context.Context, T) error) Handler {
+	return each[T]{decode, fn}
+}
+
+// Batched calls batch for all messages of a worker batch at once and falls
+// back to fn per message when the batch fails.
+func Batched[T any](decode Decode[T], batch func(context.Context, []T) error, fn func(context.Context, T) error) Handler {
+	return batched[T]{decode, batch, fn}
+}
+
+type each[T any] struct {
+	decode Decode[T]
+	fn     func(context.Context, T) error
+}
+
+func (h each[T]) handle(ctx context.Context, c *consumer, msgs []kafka.Message) {
+	for _, msg := range msgs {
+		v, ok := decodeMessage(ctx, c, msg, h.decode)
+		if !ok {
+			continue
+		}
+		if !c.apply(ctx, msg, func(ctx context.Context) error { return h.fn(ctx, v) }) {
+			return
+		}
+	}
+}
+
+type batched[T any] struct {
+	decode Decode[T]
+	batch  func(context.Context, []T) error
+	fn     func(context.Context, T) error
+}
+
+func (h batched[T]) handle(ctx context.Context, c *consumer, msgs []kafka.Message) {
+	vals := make([]T, 0, len(msgs))
+	kept := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		v, ok := decodeMessage(ctx, c, msg, h.decode)
+		if !ok {
+			continue
+		}
+		vals = append(vals, v)
+		kept = append(kept, msg)
+	}
+
+	if len(vals) > 1 {
+		err := h.batch(ctx, vals)
+		c.observe(err)
+		if err == nil {
+			c.count("handled", len(vals))
+			for _, msg := range kept {
+				c.finish(msg)
+			}
+			return
+		}
+		// one bad message must not block the rest of the batch
+		log.Printf("batch of %d from %s failed, falling back to single messages: %v", len(vals), c.route.Topic, err)
+		metrics.ConsumerBatchFallbacks.WithLabelValues(c.route.Topic).Inc()
+	}
+
+	for i, v := range vals {
+		if !c.apply(ctx, kept[i], func(ctx context.Context) error { return h.fn(ctx, v) }) {
+			return
+		}
+	}
+}
+
+// decodeMessage parks messages of unknown versions whatever the policy,
+// other broken payloads follow the error policy of the route.
+func decodeMessage[T any](ctx context.Context, c *consumer, msg kafka.Message, decode Decode[T]) (T, bool) {
+	v, err := decode(ctx, msg)
+	if errors.Is(err, codec.ErrUnknownSchemaVersion) || errors.Is(err, codec.ErrUnknownEventType) {
+		c.park(msg, err)
+		c.finish(msg)
+		return v, false
+	}
+	if err != nil {
+		log.Printf("bad payload at %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		c.count("bad_payload", 1)
+		c.fail(msg, err)
+		c.finish(msg)
+		return v, false
+	}
+	return v, true
+}
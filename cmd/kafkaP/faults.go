@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"math/rand"
+	"order-service/internal/codec"
+	"order-service/internal/domain"
+	"strings"
+	"time"
+)
+
+// faultHeader tags every injected message with its fault, so an end-to-end
+// test can find it in the parking topic, the logs or the database.
+const faultHeader = "x-fault"
+
+const (
+	FaultMalformed      = "malformed"
+	FaultDuplicate      = "duplicate"
+	FaultConstraint     = "constraint"
+	FaultOutOfOrder     = "out_of_order"
+	FaultOversized      = "oversized"
+	FaultUnknownVersion = "unknown_version"
+)
+
+// faultMix holds the share of messages per fault in percent, a message
+// carries at most one fault.
+type faultMix struct {
+	names   []string
+	percent []float64
+}
+
+func (m *faultMix) add(name string, percent float64) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("-%s %g: want a percentage", strings.ReplaceAll(name, "_", "-"), percent)
+	}
+	if percent > 0 {
+		m.names = append(m.names, name)
+		m.percent = append(m.percent, percent)
+	}
+	var total float64
+	for _, p := range m.percent {
+		total += p
+	}
+	if total > 100 {
+		return fmt.Errorf("fault percentages add up to %g", total)
+	}
+	return nil
+}
+
+func (m faultMix) draw(rng *rand.Rand) string {
+	if len(m.names) == 0 {
+		return ""
+	}
+	x := rng.Float64() * 100
+	for i, p := range m.percent {
+		if x < p {
+			return m.names[i]
+		}
+		x -= p
+	}
+	return ""
+}
+
+// faults injects bad traffic into the generated stream.
+type faults struct {
+	mix           faultMix
+	statusTopic   string
+	oversizeBytes int
+
+	prev *domain.Order // last order sent intact, source of duplicates
+}
+
+// messages returns the messages for the n-th order, with a fault drawn
+// from the mix. Out of order status events go to the status topic.
+func (g *generator) messages(n int, topic string) ([]kafka.Message, error) {
+	ord := g.order(n)
+	fault := g.faults.mix.draw(g.rng)
+
+	switch fault {
+	case FaultDuplicate:
+		if g.faults.prev == nil {
+			fault = "" // nothing to duplicate yet
+			break
+		}
+		ord.OrderId = g.faults.prev.OrderId
+		ord.Payment.TransactionId = g.faults.prev.Payment.TransactionId
+	case FaultConstraint:
+		if g.rng.Intn(2) == 0 {
+			ord.TrackNumber = strings.Repeat("T", 32+g.rng.Intn(16))
+		} else {
+			ord.Payment.Currency = "USDT"
+		}
+	case FaultOversized:
+		ord.Delivery.Address = strings.Repeat("x", g.faults.oversizeBytes)
+	}
+
+	msg, err := orderMessage(ord)
+	if err != nil {
+		return nil, err
+	}
+	msg.Topic = topic
+
+	switch fault {
+	case FaultMalformed:
+		msg.Value = msg.Value[:len(msg.Value)/2]
+	case FaultUnknownVersion:
+		if msg.Value, err = rewrapVersion(msg.Value, codec.CurrentOrderVersion+100); err != nil {
+			return nil, err
+		}
+	case "":
+		g.faults.prev = &ord
+	}
+
+	msgs := []kafka.Message{msg}
+	if fault == FaultOutOfOrder {
+		// the order itself is fine, its status events arrive newest first
+		created := ord.DateCreated
+		for _, st := range []domain.StatusUpdate{
+			{OrderUID: ord.OrderId, Status: "delivered", UpdatedAt: created.Add(2 * time.Hour)},
+			{OrderUID: ord.OrderId, Status: "in_transit", UpdatedAt: created.Add(time.Hour)},
+		} {
+			value, err := json.Marshal(st)
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, kafka.Message{
+				Topic:   g.faults.statusTopic,
+				Key:     msg.Key,
+				Value:   value,
+				Time:    time.Now(),
+				Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+			})
+		}
+		g.faults.prev = &ord
+	}
+
+	if fault != "" {
+		for i := range msgs {
+			msgs[i].Headers = append(msgs[i].Headers, kafka.Header{Key: faultHeader, Value: []byte(fault)})
+		}
+	}
+	return msgs, nil
+}
+
+// rewrapVersion replaces the schema version of an enveloped message.
+func rewrapVersion(value []byte, version int) ([]byte, error) {
+	var env codec.Envelope
+	if err := json.Unmarshal(value, &env); err != nil {
+		return nil, err
+	}
+	env.SchemaVersion = version
+	return json.Marshal(env)
+}
+
+func faultOf(msg kafka.Message) string {
+	for _, h := range msg.Headers {
+		if h.Key == faultHeader {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"golang.org/x/time/rate"
+	"log"
+	"order-service/internal/fake"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// gen publishes synthetic orders at a target rate and prints a throughput
+// and latency summary, which makes it a load generator for kafkaC.
+//
+//	kafkaP gen -rate 2000 -duration 1m -concurrency 16 -profile black-friday -seed 42
+func gen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	brokers := fs.String("brokers", "", "comma separated brokers, KAFKA_BROKERS by default")
+	topic := fs.String("topic", "", "topic, KAFKA_TOPIC by default")
+	ratePerSec := fs.Float64("rate", 1, "messages per second, 0 for as fast as possible")
+	count := fs.Int("count", 0, "stop after this many messages, 0 for no limit")
+	duration := fs.Duration("duration", 0, "stop after this long, 0 for no limit")
+	concurrency := fs.Int("concurrency", 1, "parallel writes")
+	profile := fs.String("profile", "default", "scenario profile of generated orders")
+	profiles := fs.String("profiles", "", "YAML file with scenario profiles, the built-in ones by default")
+	items := fs.String("items", "", "items per order: N, MIN-MAX or N:WEIGHT,..., overrides the profile")
+	currencies := fs.String("currencies", "", "comma separated currencies picked uniformly, overrides the profile")
+	seed := fs.Int64("seed", 0, "random seed for reproducible orders, 0 picks one from the clock")
+	batchSize := fs.Int("batch-size", 100, "writer batch size")
+	batchTimeout := fs.Duration("batch-timeout", 10*time.Millisecond, "writer batch timeout")
+
+	// fault injection, percent of generated orders
+	malformed := fs.Float64("malformed", 0, "percent of orders cut in half, invalid JSON")
+	duplicates := fs.Float64("duplicates", 0, "percent of orders reusing the order_uid of an earlier one")
+	constraint := fs.Float64("constraint", 0, "percent of orders violating DB constraints: long track number or 4-letter currency")
+	outOfOrder := fs.Float64("out-of-order", 0, "percent of orders followed by status events newest first")
+	oversized := fs.Float64("oversized", 0, "percent of orders padded to -oversize-bytes")
+	unknownVersion := fs.Float64("unknown-version", 0, "percent of orders with an unknown schema version")
+	statusTopic := fs.String("status-topic", os.Getenv("KAFKA_DELIVERY_TOPIC"), "topic of out of order status events")
+	oversizeBytes := fs.Int("oversize-bytes", 2<<20, "size of oversized orders")
+	_ = fs.Parse(args)
+
+	if *topic == "" {
+		*topic = defaultTopic()
+	}
+	if *concurrency < 1 {
+		*concurrency = 1
+	}
+	prof, err := fake.LookupProfile(*profiles, *profile)
+	if err != nil {
+		log.Fatalf("bad -profile: %v", err)
+	}
+	if *items != "" {
+		if prof.Items, err = parseItemDist(*items); err != nil {
+			log.Fatalf("bad -items: %v", err)
+		}
+	}
+	if *currencies != "" {
+		prof.Currencies = parseCurrencies(*currencies)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g, err := newGenerator(*seed, prof)
+	if err != nil {
+		log.Fatalf("bad -profile: %v", err)
+	}
+	g.faults.statusTopic = *statusTopic
+	g.faults.oversizeBytes = *oversizeBytes
+	for _, f := range []struct {
+		name    string
+		percent float64
+	}{
+		{FaultMalformed, *malformed},
+		{FaultDuplicate, *duplicates},
+		{FaultConstraint, *constraint},
+		{FaultOutOfOrder, *outOfOrder},
+		{FaultOversized, *oversized},
+		{FaultUnknownVersion, *unknownVersion},
+	} {
+		if err := g.faults.mix.add(f.name, f.percent); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *outOfOrder > 0 && *statusTopic == "" {
+		log.Fatal("-out-of-order needs -status-topic or KAFKA_DELIVERY_TOPIC")
+	}
+
+	w := newWriter(*brokers, "", *batchSize, *batchTimeout)
+	if *oversized > 0 {
+		// let oversized messages reach the broker instead of failing in the writer
+		w.BatchBytes = int64(*oversizeBytes) + 64<<10
+	}
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	var limiter *rate.Limiter
+	if *ratePerSec > 0 {
+		limiter = rate.NewLimiter(rate.Limit(*ratePerSec), *concurrency)
+	}
+
+	log.Printf("generating %s to %s, rate %s, %d writers, seed %d", limitText(*count, *duration), *topic, rateText(*ratePerSec), *concurrency, *seed)
+
+	// one goroutine draws all orders so a seed always yields the same sequence
+	sends := make(chan []kafka.Message, *concurrency)
+	go func() {
+		defer close(sends)
+		for n := 0; *count == 0 || n < *count; n++ {
+			msgs, err := g.messages(n, *topic)
+			if err != nil {
+				log.Printf("encode: %v", err)
+				continue
+			}
+			select {
+			case sends <- msgs:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	st := newStats()
+	go st.progress(ctx, 5*time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msgs := range sends {
+				if limiter != nil && limiter.Wait(ctx) != nil {
+					return
+				}
+				start := time.Now()
+				err := w.WriteMessages(ctx, msgs...)
+				if err != nil && ctx.Err() != nil {
+					return // cut off by the deadline, not a broker failure
+				}
+				if err != nil {
+					log.Printf("write: %v", err)
+				}
+				st.observe(time.Since(start), msgs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	log.Println("stopped")
+	st.summary(os.Stdout)
+}
+
+func limitText(count int, duration time.Duration) string {
+	switch {
+	case count > 0 && duration > 0:
+		return fmt.Sprintf("%d messages within %s", count, duration)
+	case count > 0:
+		return fmt.Sprintf("%d messages", count)
+	case duration > 0:
+		return fmt.Sprintf("for %s", duration)
+	}
+	return "until interrupted"
+}
+
+func rateText(r float64) string {
+	if r <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g msg/s", r)
+}
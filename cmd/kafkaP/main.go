@@ -1,17 +1,17 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/segmentio/kafka-go"
 	"log"
-	"math/rand"
+	"order-service/internal/codec"
 	"order-service/internal/domain"
+	"order-service/internal/kafkaconn"
 	"os"
-	"os/signal"
+	"strings"
 	"time"
 )
 
@@ -19,87 +19,76 @@ func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("⚠️  .env file not found or failed to load, fallback to OS env")
 	}
-	brokers := os.Getenv("KAFKA_BROKERS")
-	if brokers == "" {
-		fmt.Println("KAFKA_BROKERS environment variable not set")
-		brokers = "localhost:29092"
+
+	cmd, args := "gen", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
+	switch cmd {
+	case "gen":
+		gen(args)
+	case "publish":
+		publish(args)
+	default:
+		log.Fatalf("unknown command %q, want gen or publish", cmd)
+	}
+}
+
+func defaultTopic() string {
 	topic := os.Getenv("KAFKA_TOPIC")
 	if topic == "" {
+		fmt.Println("KAFKA_TOPIC environment variable not set")
 		topic = "orders-topic"
-		fmt.Println("KAFKA_BROKERS environment variable not set")
 	}
-	interval := time.Second
+	return topic
+}
 
-	// ── writer ──────────────────────────────────────────────────
-	w := &kafka.Writer{
-		Addr:         kafka.TCP(brokers),
-		Topic:        topic,
-		Balancer:     &kafka.Hash{},
-		RequiredAcks: kafka.RequireAll,
+// newWriter connects with the KAFKA_* settings, brokers overrides KAFKA_BROKERS when set.
+// An empty topic leaves it to every message.
+func newWriter(brokers, topic string, batchSize int, batchTimeout time.Duration) *kafka.Writer {
+	kafkaCfg := kafkaconn.FromEnv()
+	if brokers != "" {
+		kafkaCfg.Brokers = strings.Split(brokers, ",")
 	}
-	defer w.Close()
-
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
-	defer stop()
-
-	log.Printf("emitter → %s (%s) every %s", brokers, topic, interval)
-
-	for n := 0; ; n++ {
-		select {
-		case <-ctx.Done():
-			log.Println("stopped")
-			return
-		default:
-			ord := makeDummyOrder(n)
-			payload, _ := json.Marshal(ord)
-
-			msg := kafka.Message{
-				Key:   []byte(ord.OrderId.String()),
-				Value: payload,
-				Time:  time.Now(),
-			}
-			if err := w.WriteMessages(ctx, msg); err != nil {
-				log.Printf("write: %v", err)
-			} else {
-				log.Printf("sent %s", ord.OrderId)
-			}
-			time.Sleep(interval)
-		}
+	if len(kafkaCfg.Brokers) == 0 {
+		fmt.Println("KAFKA_BROKERS environment variable not set")
+		kafkaCfg.Brokers = []string{"localhost:29092"}
 	}
+	conn, err := kafkaCfg.Connect()
+	if err != nil {
+		log.Fatalf("kafka: %v", err)
+	}
+
+	w := conn.Writer(topic)
+	w.Balancer = &kafka.Hash{}
+	w.BatchSize = batchSize
+	w.BatchTimeout = batchTimeout
+	log.Printf("emitter → %s", strings.Join(conn.Brokers, ","))
+	return w
 }
 
-func makeDummyOrder(n int) domain.Order {
-	uid := uuid.New()
-	return domain.Order{
-		OrderId:     uid,
-		TrackNumber: fmt.Sprintf("TESTTRACK-%06d", n),
-		Entry:       "WBIL",
-		Locale:      "en",
-		CustomerId:  "emitter",
-		ShardKey:    int64(rand.Intn(1000)),
-		SmId:        rand.Intn(1000),
-		DateCreated: time.Now(),
-		Delivery: domain.Delivery{
-			Name:    "Load Gen",
-			Phone:   "+100000000",
-			City:    "GoCity",
-			Address: "Benchmark str.",
-		},
-		Payment: domain.Payment{
-			TransactionId: uid.String(),
-			Amount:        int64(rand.Intn(5000) + 500),
-			Currency:      "USD",
-			Provider:      "emitter-pay",
-		},
-		Items: []domain.Item{{
-			ChrtId:      int64(rand.Intn(1e7)),
-			TrackNumber: "TESTTRACK",
-			Name:        "DemoItem",
-			Price:       999,
-			TotalPrice:  999,
-			Brand:       "EmitterCo",
-			Status:      "202",
-		}},
+// orderMessage wraps the order into the current envelope, keyed by order_uid.
+func orderMessage(ord domain.Order) (kafka.Message, error) {
+	order, err := json.Marshal(ord)
+	if err != nil {
+		return kafka.Message{}, err
 	}
+	payload, err := json.Marshal(codec.Envelope{
+		EventType:      domain.EventOrderCreated,
+		SchemaVersion:  codec.CurrentOrderVersion,
+		Producer:       "kafkaP",
+		OccurredAt:     time.Now().UTC(),
+		IdempotencyKey: uuid.NewString(),
+		Payload:        order,
+	})
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:     []byte(ord.OrderId.String()),
+		Value:   payload,
+		Time:    time.Now(),
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+	}, nil
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"order-service/internal/domain"
+	"order-service/internal/fake"
+	"strconv"
+	"strings"
+)
+
+// parseItemDist accepts "3" (always three), "1-5" (uniform) or
+// "1:60,2:25,5:15" (weighted).
+func parseItemDist(s string) (fake.Weights[int], error) {
+	d := fake.Weights[int]{}
+	add := func(n, w int) error {
+		if n < 1 || w < 1 {
+			return fmt.Errorf("%q: counts and weights must be positive", s)
+		}
+		d[n] += w
+		return nil
+	}
+
+	switch {
+	case strings.Contains(s, ":"):
+		for _, part := range strings.Split(s, ",") {
+			n, w, _ := strings.Cut(strings.TrimSpace(part), ":")
+			cnt, err1 := strconv.Atoi(n)
+			weight, err2 := strconv.Atoi(w)
+			if err := errors.Join(err1, err2); err != nil {
+				return d, fmt.Errorf("%q: %w", part, err)
+			}
+			if err := add(cnt, weight); err != nil {
+				return d, err
+			}
+		}
+	case strings.Contains(s, "-"):
+		lo, hi, _ := strings.Cut(s, "-")
+		min, err1 := strconv.Atoi(lo)
+		max, err2 := strconv.Atoi(hi)
+		if err := errors.Join(err1, err2); err != nil {
+			return d, err
+		}
+		if max < min {
+			return d, fmt.Errorf("%q: max below min", s)
+		}
+		for n := min; n <= max; n++ {
+			if err := add(n, 1); err != nil {
+				return d, err
+			}
+		}
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return d, err
+		}
+		if err := add(n, 1); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// parseCurrencies turns "USD,EUR" into equal weights.
+func parseCurrencies(s string) fake.Weights[string] {
+	w := fake.Weights[string]{}
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			w[c] = 1
+		}
+	}
+	return w
+}
+
+// generator draws orders of a fake profile plus the faults injected into
+// them, it is not safe for concurrent use.
+type generator struct {
+	orders *fake.Generator
+	rng    *rand.Rand
+	faults faults
+}
+
+func newGenerator(seed int64, p fake.Profile) (*generator, error) {
+	orders, err := fake.New(seed, p)
+	if err != nil {
+		return nil, err
+	}
+	return &generator{orders: orders, rng: rand.New(rand.NewSource(seed))}, nil
+}
+
+func (g *generator) order(int) domain.Order {
+	return g.orders.Order()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"log"
+	"order-service/internal/codec"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	keyUID      = "uid"
+	keyOriginal = "original"
+	keyRandom   = "random"
+
+	publishBatch = 100
+	maxLineBytes = 16 << 20
+)
+
+// record is one order read from a sample file, line is the 1-based line of
+// an .ndjson file or the 1-based element of a .json array.
+type record struct {
+	file string
+	line int
+	data []byte
+}
+
+func (r record) String() string {
+	return fmt.Sprintf("%s:%d", r.file, r.line)
+}
+
+// publish sends recorded orders from .ndjson or .json files, or from every
+// such file of a directory, after validating them as orders.
+//
+//	kafkaP publish -file samples/ -fresh-uid -fresh-date -key random
+func publish(args []string) {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	path := fs.String("file", "", "an .ndjson or .json file, or a directory of them")
+	brokers := fs.String("brokers", "", "comma separated brokers, KAFKA_BROKERS by default")
+	topic := fs.String("topic", "", "topic, KAFKA_TOPIC by default")
+	freshUID := fs.Bool("fresh-uid", false, "replace order_uid (and a transaction_id equal to it) with a new one")
+	freshDate := fs.Bool("fresh-date", false, "replace date_created with the current time")
+	key := fs.String("key", keyUID, "message key: uid (order_uid as published), original (order_uid in the file) or random")
+	strict := fs.Bool("strict", true, "reject unknown fields besides missing required ones")
+	dryRun := fs.Bool("dry-run", false, "only validate")
+	_ = fs.Parse(args)
+
+	if *path == "" {
+		log.Fatal("-file is required")
+	}
+	if *key != keyUID && *key != keyOriginal && *key != keyRandom {
+		log.Fatalf("bad -key %q, want %s, %s or %s", *key, keyUID, keyOriginal, keyRandom)
+	}
+	if *topic == "" {
+		*topic = defaultTopic()
+	}
+
+	files, err := sampleFiles(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var w *kafka.Writer
+	if !*dryRun {
+		w = newWriter(*brokers, *topic, publishBatch, 10*time.Millisecond)
+		defer w.Close()
+	}
+
+	dec := codec.NewJSONDecoder(codec.DefaultUpcasters(), *strict)
+	var failures []string
+	fail := func(r record, err error) {
+		failures = append(failures, fmt.Sprintf("%s: %v", r, err))
+	}
+
+	sent, valid := 0, 0
+	for _, file := range files {
+		var batch []kafka.Message
+		var batchRecords []record
+
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			err := w.WriteMessages(ctx, batch...)
+			var werrs kafka.WriteErrors
+			switch {
+			case errors.As(err, &werrs):
+				for i, e := range werrs {
+					if e != nil {
+						fail(batchRecords[i], e)
+					} else {
+						sent++
+					}
+				}
+			case err != nil:
+				for _, r := range batchRecords {
+					fail(r, err)
+				}
+			default:
+				sent += len(batch)
+			}
+			batch, batchRecords = batch[:0], batchRecords[:0]
+		}
+
+		err := readRecords(file, func(r record) {
+			ord, err := dec.Decode(ctx, r.data)
+			if err != nil {
+				fail(r, err)
+				return
+			}
+			valid++
+			if *dryRun {
+				return
+			}
+
+			original := ord.OrderId
+			if *freshUID {
+				ord.OrderId = uuid.New()
+				if ord.Payment.TransactionId == original.String() {
+					ord.Payment.TransactionId = ord.OrderId.String()
+				}
+			}
+			if *freshDate {
+				ord.DateCreated = time.Now()
+			}
+
+			msg, err := orderMessage(*ord)
+			if err != nil {
+				fail(r, err)
+				return
+			}
+			switch *key {
+			case keyOriginal:
+				msg.Key = []byte(original.String())
+			case keyRandom:
+				msg.Key = []byte(uuid.NewString())
+			}
+			msg.Topic = *topic
+
+			batch = append(batch, msg)
+			batchRecords = append(batchRecords, r)
+			if len(batch) == publishBatch {
+				flush()
+			}
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", file, err))
+		}
+		if !*dryRun {
+			flush()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, f := range failures {
+		fmt.Println(f)
+	}
+	fmt.Printf("%d files, %d valid orders, %d sent, %d failures\n", len(files), valid, sent, len(failures))
+	if len(failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+// sampleFiles expands a directory into its .ndjson and .json files in name order.
+func sampleFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if !e.IsDir() && (ext == ".ndjson" || ext == ".json") {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .ndjson or .json files in %s", path)
+	}
+	return files, nil
+}
+
+// readRecords calls fn for every order of the file. A .json file holds one
+// order or an array of them, any other file one order per line.
+func readRecords(file string, fn func(record)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 || data[0] != '[' {
+			fn(record{file, 1, data})
+			return nil
+		}
+		var list []json.RawMessage
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		for i, raw := range list {
+			fn(record{file, i + 1, raw})
+		}
+		return nil
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), maxLineBytes)
+	for line := 1; sc.Scan(); line++ {
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		fn(record{file, line, append([]byte(nil), data...)})
+	}
+	return sc.Err()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stats collects write latencies for the final summary.
+type stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	latencies []time.Duration
+	bytes     int64
+	sent      int
+	failed    int
+	faults    map[string]int
+}
+
+func newStats() *stats {
+	return &stats{start: time.Now(), faults: make(map[string]int)}
+}
+
+// observe records one write of the messages of one order.
+func (s *stats) observe(d time.Duration, msgs []kafka.Message, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.failed += len(msgs)
+		return
+	}
+	s.latencies = append(s.latencies, d)
+	for _, msg := range msgs {
+		s.sent++
+		s.bytes += int64(len(msg.Value))
+		if f := faultOf(msg); f != "" {
+			s.faults[f]++
+		}
+	}
+}
+
+func (s *stats) progress(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+
+	last := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		s.mu.Lock()
+		sent, failed := s.sent, s.failed
+		s.mu.Unlock()
+		log.Printf("sent %d (%.0f msg/s), failed %d", sent, float64(sent-last)/every.Seconds(), failed)
+		last = sent
+	}
+}
+
+func (s *stats) summary(out io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := time.Since(s.start)
+	fmt.Fprintf(out, "sent        %d messages (%.2f MB), %d failed in %s\n", s.sent, float64(s.bytes)/1e6, s.failed, elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "throughput  %.1f msg/s, %.2f MB/s\n", float64(s.sent)/elapsed.Seconds(), float64(s.bytes)/1e6/elapsed.Seconds())
+	if len(s.faults) > 0 {
+		names := make([]string, 0, len(s.faults))
+		for f := range s.faults {
+			names = append(names, f)
+		}
+		sort.Strings(names)
+		fmt.Fprint(out, "faults     ")
+		for _, f := range names {
+			fmt.Fprintf(out, " %s %d", f, s.faults[f])
+		}
+		fmt.Fprintln(out)
+	}
+	if len(s.latencies) == 0 {
+		return
+	}
+
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	fmt.Fprintf(out, "latency     p50 %s  p90 %s  p99 %s  max %s\n",
+		s.percentile(0.50), s.percentile(0.90), s.percentile(0.99), s.latencies[len(s.latencies)-1].Round(time.Microsecond))
+}
+
+// percentile expects sorted latencies and s.mu held.
+func (s *stats) percentile(p float64) time.Duration {
+	i := int(p * float64(len(s.latencies)-1))
+	return s.latencies[i].Round(time.Microsecond)
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"order-service/internal/domain"
+	"slices"
+)
+
+// analyticsLockKey is the advisory lock taken by the instance refreshing the analytics views.
+const analyticsLockKey = 0x616e616c79
+
+// rateJoin values a daily view row in the base currency ($3) at the latest
+// rate on or before its day, rate is NULL when there is none.
+const rateJoin = `CROSS JOIN LATERAL (SELECT CASE WHEN v.currency = $3 THEN 1
+                      ELSE (SELECT r.rate FROM exchange_rates r
+                            WHERE r.currency = v.currency AND r.rate_date <= v.day
+                            ORDER BY r.rate_date DESC LIMIT 1) END AS rate) x`
+
+func (p *PgRepo) Sales(ctx context.Context, q domain.AnalyticsQuery, dimension, period string) ([]domain.SalesRow, error) {
+	if !slices.Contains(domain.AnalyticsDimensions, dimension) {
+		return nil, fmt.Errorf("unknown dimension %q", dimension)
+	}
+	salesSQL := fmt.Sprintf(`SELECT date_trunc($5::text, v.day::timestamp)::date, v.%s::text,
+                       sum(v.orders)::bigint,
+                       round(COALESCE(sum(v.revenue * x.rate), 0), $4),
+                       round(COALESCE(sum(v.goods_total * x.rate), 0), $4),
+                       round(COALESCE(sum(v.delivery_cost * x.rate), 0), $4),
+                       sum(v.items)::bigint, sum(v.sale_items)::bigint,
+                       COALESCE(sum(v.orders) FILTER (WHERE x.rate IS NULL), 0)::bigint
+                       FROM analytics_orders_daily v
+                       %s
+                       WHERE v.day BETWEEN $1 AND $2
+                       GROUP BY 1, 2 ORDER BY 1, 2`, dimension, rateJoin)
+
+	rows, err := p.pool.Query(ctx, salesSQL, q.From, q.To, q.Currency, domain.Exponent(q.Currency), period)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]domain.SalesRow, 0)
+	for rows.Next() {
+		r := domain.SalesRow{
+			Revenue:      domain.NewMoney(0, q.Currency),
+			GoodsTotal:   domain.NewMoney(0, q.Currency),
+			DeliveryCost: domain.NewMoney(0, q.Currency),
+		}
+		var saleItems int64
+		if err = rows.Scan(&r.Period, &r.Key, &r.Orders, &r.Revenue, &r.GoodsTotal, &r.DeliveryCost,
+			&r.Items, &saleItems, &r.Unpriced); err != nil {
+			return nil, err
+		}
+		r.AverageBasket = r.Revenue.Div(r.Orders - r.Unpriced)
+		r.ItemsPerOrder = ratio(r.Items, r.Orders)
+		r.SaleShare = ratio(saleItems, r.Items)
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (p *PgRepo) TopSales(ctx context.Context, q domain.AnalyticsQuery, by string, limit int) ([]domain.TopRow, error) {
+	if by != domain.TopByBrand && by != domain.TopByNmID {
+		return nil, fmt.Errorf("unknown ranking %q", by)
+	}
+	topSQL := fmt.Sprintf(`SELECT v.%s::text,
+                     round(COALESCE(sum(v.revenue * x.rate), 0), $4),
+                     sum(v.units)::bigint, sum(v.sale_units)::bigint,
+                     COALESCE(sum(v.units) FILTER (WHERE x.rate IS NULL), 0)::bigint
+                     FROM analytics_items_daily v
+                     %s
+                     WHERE v.day BETWEEN $1 AND $2
+                     GROUP BY 1 ORDER BY 2 DESC, 1 LIMIT $5`, by, rateJoin)
+
+	rows, err := p.pool.Query(ctx, topSQL, q.From, q.To, q.Currency, domain.Exponent(q.Currency), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]domain.TopRow, 0, limit)
+	for rows.Next() {
+		r := domain.TopRow{Revenue: domain.NewMoney(0, q.Currency)}
+		var saleUnits int64
+		if err = rows.Scan(&r.Key, &r.Revenue, &r.Units, &saleUnits, &r.Unpriced); err != nil {
+			return nil, err
+		}
+		r.SaleShare = ratio(saleUnits, r.Units)
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (p *PgRepo) Summary(ctx context.Context, q domain.AnalyticsQuery) (domain.SalesSummary, error) {
+	summarySQL := `SELECT COALESCE(sum(v.orders), 0)::bigint,
+                   round(COALESCE(sum(v.revenue * x.rate), 0), $4),
+                   COALESCE(sum(v.items), 0)::bigint, COALESCE(sum(v.sale_items), 0)::bigint,
+                   COALESCE(sum(v.orders) FILTER (WHERE x.rate IS NULL), 0)::bigint
+                   FROM analytics_orders_daily v
+                   ` + rateJoin + `
+                   WHERE v.day BETWEEN $1 AND $2`
+
+	s := domain.SalesSummary{Revenue: domain.NewMoney(0, q.Currency)}
+	var saleItems int64
+	err := p.pool.QueryRow(ctx, summarySQL, q.From, q.To, q.Currency, domain.Exponent(q.Currency)).
+		Scan(&s.Orders, &s.Revenue, &s.Items, &saleItems, &s.Unpriced)
+	if err != nil {
+		return s, err
+	}
+	s.AverageBasket = s.Revenue.Div(s.Orders - s.Unpriced)
+	s.ItemsPerOrder = ratio(s.Items, s.Orders)
+	s.SaleShare = ratio(saleItems, s.Items)
+	return s, nil
+}
+
+func (p *PgRepo) RefreshAnalytics(ctx context.Context) (bool, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, int64(analyticsLockKey)).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+
+	for _, view := range []string{"analytics_orders_daily", "analytics_items_daily"} {
+		if _, err = tx.Exec(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			return false, fmt.Errorf("refresh %s: %w", view, err)
+		}
+	}
+	return true, tx.Commit(ctx)
+}
+
+func ratio(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
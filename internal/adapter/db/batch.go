@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"order-service/internal/domain"
+	"strconv"
+)
+
+var (
+	orderColumns    = []string{"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id", "delivery_service", "shardkey", "sm_id", "date_created"}
+	deliveryColumns = []string{"order_uid", "del_name", "phone", "zip", "city", "address", "region", "email"}
+	paymentColumns  = []string{"order_uid", "transaction_id", "request_id", "currency", "provider", "amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"}
+	itemColumns     = []string{"order_uid", "chrt_id", "track_number", "price", "rid", "item_name", "sale", "item_size", "total_price", "nm_id", "brand", "status"}
+)
+
+// SaveBatch copies the orders into temporary staging tables and merges them
+// in one transaction. Orders already stored are skipped, so a redelivered
+// batch is harmless; it returns the ids of the orders it inserted.
+func (p *PgRepo) SaveBatch(ctx context.Context, orders []*domain.Order) ([]uuid.UUID, error) {
+	return p.saveBatch(ctx, orders, true)
+}
+
+// SeedBatch is SaveBatch without the outbox events, generated orders must
+// not reach the orders topic or the webhook subscribers.
+func (p *PgRepo) SeedBatch(ctx context.Context, orders []*domain.Order) ([]uuid.UUID, error) {
+	return p.saveBatch(ctx, orders, false)
+}
+
+func (p *PgRepo) saveBatch(ctx context.Context, orders []*domain.Order, outbox bool) ([]uuid.UUID, error) {
+	orders = uniqueOrders(orders)
+
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	const stagingSQL = `
+CREATE TEMP TABLE stg_orders ON COMMIT DROP AS SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service, shardkey, sm_id, date_created FROM orders WITH NO DATA;
+CREATE TEMP TABLE stg_deliveries ON COMMIT DROP AS SELECT order_uid, del_name, phone, zip, city, address, region, email FROM deliveries WITH NO DATA;
+CREATE TEMP TABLE stg_payments ON COMMIT DROP AS SELECT order_uid, transaction_id, request_id, currency, provider, amount, payment_dt, bank, delivery_cost, goods_total, custom_fee FROM payments WITH NO DATA;
+CREATE TEMP TABLE stg_items ON COMMIT DROP AS SELECT order_uid, chrt_id, track_number, price, rid, item_name, sale, item_size, total_price, nm_id, brand, status FROM items WITH NO DATA;`
+
+	if _, err = tx.Exec(ctx, stagingSQL); err != nil {
+		return nil, err
+	}
+
+	orderRows := make([][]any, 0, len(orders))
+	delRows := make([][]any, 0, len(orders))
+	payRows := make([][]any, 0, len(orders))
+	itemRows := make([][]any, 0, len(orders))
+
+	for _, o := range orders {
+		orderRows = append(orderRows, []any{o.OrderId, o.TrackNumber, o.Entry, o.Locale, o.InternalSignature,
+			o.CustomerId, o.DeliveryService, o.ShardKey, o.SmId, o.DateCreated})
+		delRows = append(delRows, []any{o.OrderId, o.Delivery.Name, o.Delivery.Phone, o.Delivery.Zip,
+			o.Delivery.City, o.Delivery.Address, o.Delivery.Region, o.Delivery.Email})
+		payRows = append(payRows, []any{o.OrderId, o.Payment.TransactionId, o.Payment.RequestId, o.Payment.Currency,
+			o.Payment.Provider, o.Payment.Amount, o.Payment.PaymentDt, o.Payment.Bank, o.Payment.DeliveryCost,
+			o.Payment.GoodsTotal, o.Payment.CustomFee})
+
+		for _, item := range o.Items {
+			// COPY is binary, the status has to be an integer already
+			status, err := strconv.Atoi(item.Status)
+			if err != nil {
+				return nil, fmt.Errorf("order %s: item status %q: %w", o.OrderId, item.Status, err)
+			}
+			itemRows = append(itemRows, []any{o.OrderId, item.ChrtId, item.TrackNumber, item.Price, item.RID, item.Name,
+				item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, status})
+		}
+	}
+
+	for _, c := range []struct {
+		table   string
+		columns []string
+		rows    [][]any
+	}{
+		{"stg_orders", orderColumns, orderRows},
+		{"stg_deliveries", deliveryColumns, delRows},
+		{"stg_payments", paymentColumns, payRows},
+		{"stg_items", itemColumns, itemRows},
+	} {
+		if _, err = tx.CopyFrom(ctx, pgx.Identifier{c.table}, c.columns, pgx.CopyFromRows(c.rows)); err != nil {
+			return nil, fmt.Errorf("copy %s: %w", c.table, err)
+		}
+	}
+
+	const mergeOrdersSQL = `INSERT INTO orders(order_uid, track_number, entry, locale, internal_signature,
+                            customer_id, delivery_service, shardkey, sm_id, date_created)
+                            SELECT order_uid, track_number, entry, locale, internal_signature,
+                                   customer_id, delivery_service, shardkey, sm_id, date_created
+                            FROM stg_orders
+                            ON CONFLICT (order_uid) DO NOTHING
+                            RETURNING order_uid`
+
+	rows, err := tx.Query(ctx, mergeOrdersSQL)
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]uuid.UUID, 0, len(orders))
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		inserted = append(inserted, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(inserted) > 0 {
+		const delSQL = `INSERT INTO deliveries(order_uid, del_name, phone, zip, city, address, region, email)
+                        SELECT order_uid, del_name, phone, zip, city, address, region, email
+                        FROM stg_deliveries WHERE order_uid = ANY($1)`
+
+		const paySQL = `INSERT INTO payments(order_uid, transaction_id, request_id, currency, provider,
+                        amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
+                        SELECT order_uid, transaction_id, request_id, currency, provider,
+                               amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
+                        FROM stg_payments WHERE order_uid = ANY($1)`
+
+		const itemSQL = `INSERT INTO items(order_uid, chrt_id, track_number, price, rid,
+                         item_name, sale, item_size, total_price, nm_id, brand, status)
+                         SELECT order_uid, chrt_id, track_number, price, rid,
+                                item_name, sale, item_size, total_price, nm_id, brand, status
+                         FROM stg_items WHERE order_uid = ANY($1)`
+
+		batch := &pgx.Batch{}
+		batch.Queue(delSQL, inserted)
+		batch.Queue(paySQL, inserted)
+		batch.Queue(itemSQL, inserted)
+		if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+			return nil, err
+		}
+
+		if outbox {
+			if err = p.copyOutbox(ctx, tx, orders, inserted); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Batch saved: %d orders, %d new", len(orders), len(inserted))
+	return inserted, nil
+}
+
+func (p *PgRepo) copyOutbox(ctx context.Context, tx pgx.Tx, orders []*domain.Order, inserted []uuid.UUID) error {
+	isNew := make(map[uuid.UUID]struct{}, len(inserted))
+	for _, id := range inserted {
+		isNew[id] = struct{}{}
+	}
+
+	rows := make([][]any, 0, len(inserted))
+	for _, o := range orders {
+		if _, ok := isNew[o.OrderId]; !ok {
+			continue
+		}
+		payload, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []any{o.OrderId, domain.EventOrderCreated, payload})
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"}, []string{"order_uid", "event_type", "payload"}, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy outbox: %w", err)
+	}
+
+	_, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, outboxChannel)
+	return err
+}
+
+// uniqueOrders keeps the first occurrence of every order_uid, later copies
+// would only violate the child tables' primary keys.
+func uniqueOrders(orders []*domain.Order) []*domain.Order {
+	seen := make(map[uuid.UUID]struct{}, len(orders))
+	result := make([]*domain.Order, 0, len(orders))
+	for _, o := range orders {
+		if _, ok := seen[o.OrderId]; ok {
+			continue
+		}
+		seen[o.OrderId] = struct{}{}
+		result = append(result, o)
+	}
+	return result
+}
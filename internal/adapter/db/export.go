@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"order-service/internal/domain"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at once.
+const exportFetchSize = 500
+
+// exportSQL has one row per item, orders without items come with a NULL
+// item_id. Rows of an order are adjacent.
+const exportSQL = `SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+                   o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.status,
+                   d.del_name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+                   p.transaction_id, p.request_id, p.currency, p.provider, p.amount, p.payment_dt, p.bank,
+                   p.delivery_cost, p.goods_total, p.custom_fee,
+                   i.item_id, COALESCE(i.chrt_id, 0), COALESCE(i.track_number, ''), i.price, COALESCE(i.rid, ''),
+                   COALESCE(i.item_name, ''), COALESCE(i.sale, 0), COALESCE(i.item_size, ''), i.total_price,
+                   COALESCE(i.nm_id, 0), COALESCE(i.brand, ''), COALESCE(i.status::text, '')
+                   FROM orders o
+                   JOIN deliveries d ON d.order_uid = o.order_uid
+                   JOIN payments p   ON p.order_uid = o.order_uid
+                   LEFT JOIN items i ON i.order_uid = o.order_uid
+                   WHERE ($1 = '' OR o.customer_id = $1)
+                     AND ($2 = '' OR o.delivery_service = $2)
+                     AND ($3 = '' OR p.currency = $3)
+                     AND ($4::timestamptz IS NULL OR o.date_created >= $4)
+                     AND ($5::timestamptz IS NULL OR o.date_created < $5)
+                   ORDER BY o.date_created, o.order_uid, i.item_id`
+
+func (p *PgRepo) ExportOrders(ctx context.Context, f domain.ExportFilter, fn func(*domain.Order) error) error {
+	// one snapshot for the whole export, however long the client takes to read it
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var from, to any
+	if !f.From.IsZero() {
+		from = f.From
+	}
+	if !f.To.IsZero() {
+		to = f.To
+	}
+	if _, err = tx.Exec(ctx, `DECLARE export_orders NO SCROLL CURSOR FOR `+exportSQL,
+		f.CustomerID, f.DeliveryService, f.Currency, from, to); err != nil {
+		return err
+	}
+
+	var cur *domain.Order
+	flush := func() error {
+		if cur == nil {
+			return nil
+		}
+		if err := cur.BindCurrency(); err != nil {
+			return err
+		}
+		return fn(cur)
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM export_orders`, exportFetchSize))
+		if err != nil {
+			return err
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			var o domain.Order
+			var it domain.Item
+			var itemID *int64
+			if err = rows.Scan(&o.OrderId, &o.TrackNumber, &o.Entry, &o.Locale, &o.InternalSignature, &o.CustomerId,
+				&o.DeliveryService, &o.ShardKey, &o.SmId, &o.DateCreated, &o.Status,
+				&o.Delivery.Name, &o.Delivery.Phone, &o.Delivery.Zip, &o.Delivery.City, &o.Delivery.Address, &o.Delivery.Region, &o.Delivery.Email,
+				&o.Payment.TransactionId, &o.Payment.RequestId, &o.Payment.Currency, &o.Payment.Provider, &o.Payment.Amount, &o.Payment.PaymentDt, &o.Payment.Bank,
+				&o.Payment.DeliveryCost, &o.Payment.GoodsTotal, &o.Payment.CustomFee,
+				&itemID, &it.ChrtId, &it.TrackNumber, &it.Price, &it.RID,
+				&it.Name, &it.Sale, &it.Size, &it.TotalPrice,
+				&it.NmID, &it.Brand, &it.Status); err != nil {
+				rows.Close()
+				return err
+			}
+
+			if cur == nil || cur.OrderId != o.OrderId {
+				if err = flush(); err != nil {
+					rows.Close()
+					return err
+				}
+				o.Items = make([]domain.Item, 0, 1)
+				cur = &o
+			}
+			if itemID != nil {
+				it.ID = *itemID
+				cur.Items = append(cur.Items, it)
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			break
+		}
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"order-service/internal/domain"
+	"time"
+)
+
+const importJobColumns = `job_id, source, format, dry_run, status, rows_done, orders_ok, orders_skipped, orders_bad,
+                          COALESCE(last_error, ''), created_at, updated_at, finished_at`
+
+func scanImportJob(row pgx.Row) (*domain.ImportJob, error) {
+	var j domain.ImportJob
+	err := row.Scan(&j.ID, &j.Source, &j.Format, &j.DryRun, &j.Status, &j.RowsDone, &j.OrdersOK, &j.OrdersSkipped, &j.OrdersBad,
+		&j.LastError, &j.CreatedAt, &j.UpdatedAt, &j.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (p *PgRepo) CreateImportJob(ctx context.Context, j *domain.ImportJob) error {
+	const jobSQL = `INSERT INTO import_jobs(source, format, dry_run, status, claim_token)
+                    VALUES ($1, $2, $3, $4, $5)
+                    RETURNING ` + importJobColumns
+
+	created, err := scanImportJob(p.pool.QueryRow(ctx, jobSQL, j.Source, j.Format, j.DryRun, j.Status, claimArg(j.Claim)))
+	if err != nil {
+		return err
+	}
+	created.Claim = j.Claim
+	*j = *created
+	return nil
+}
+
+func (p *PgRepo) FindImportJob(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	j, err := scanImportJob(p.pool.QueryRow(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE job_id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+func (p *PgRepo) ListImportJobs(ctx context.Context, limit int) ([]*domain.ImportJob, error) {
+	rows, err := p.pool.Query(ctx, `SELECT `+importJobColumns+` FROM import_jobs ORDER BY job_id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.ImportJob, 0)
+	for rows.Next() {
+		j, err := scanImportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, j)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgRepo) ImportErrors(ctx context.Context, id, afterRow int64, limit int) ([]domain.ImportRowError, error) {
+	const errSQL = `SELECT row_no, order_uid, error FROM import_job_errors
+                    WHERE job_id=$1 AND row_no > $2
+                    ORDER BY row_no LIMIT $3`
+
+	rows, err := p.pool.Query(ctx, errSQL, id, afterRow, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]domain.ImportRowError, 0)
+	for rows.Next() {
+		var e domain.ImportRowError
+		if err := rows.Scan(&e.Row, &e.OrderUID, &e.Error); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// claimArg stores a job without a claim as NULL.
+func claimArg(claim uuid.UUID) any {
+	if claim == uuid.Nil {
+		return nil
+	}
+	return claim
+}
+
+func (p *PgRepo) ClaimImportJob(ctx context.Context, id int64, lease time.Duration, claim uuid.UUID) (*domain.ImportJob, error) {
+	const claimSQL = `UPDATE import_jobs
+                      SET status=$2, claim_token=$5, last_error=NULL, updated_at=now()
+                      WHERE job_id = (
+                          SELECT job_id FROM import_jobs
+                          WHERE ($1::bigint = 0 OR job_id = $1)
+                            AND (status=$3 OR (status=$2 AND updated_at < now() - $4::interval))
+                          ORDER BY job_id LIMIT 1
+                          FOR UPDATE SKIP LOCKED)
+                      RETURNING ` + importJobColumns
+
+	j, err := scanImportJob(p.pool.QueryRow(ctx, claimSQL, id, domain.ImportRunning, domain.ImportPending, lease, claim))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	j.Claim = claim
+	return j, nil
+}
+
+func (p *PgRepo) RenewImportLease(ctx context.Context, id int64, claim uuid.UUID) (bool, error) {
+	const renewSQL = `UPDATE import_jobs SET updated_at=now()
+                      WHERE job_id=$1 AND status=$2 AND claim_token=$3`
+
+	tag, err := p.pool.Exec(ctx, renewSQL, id, domain.ImportRunning, claim)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *PgRepo) SaveImportProgress(ctx context.Context, j *domain.ImportJob, errs []domain.ImportRowError) error {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// a run that lost its job to another worker changes nothing
+	const progressSQL = `UPDATE import_jobs
+                         SET status=$2, rows_done=$3, orders_ok=$4, orders_skipped=$5, orders_bad=$6,
+                             last_error=NULLIF($7, ''), finished_at=$8, updated_at=now(),
+                             claim_token=CASE WHEN $2=$10 THEN claim_token END
+                         WHERE job_id=$1 AND status=$10 AND claim_token=$9
+                         RETURNING updated_at`
+
+	err = tx.QueryRow(ctx, progressSQL, j.ID, j.Status, j.RowsDone, j.OrdersOK, j.OrdersSkipped, j.OrdersBad, j.LastError, j.FinishedAt,
+		j.Claim, domain.ImportRunning).Scan(&j.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrImportLost
+	}
+	if err != nil {
+		return err
+	}
+
+	// a batch repeated after a crash reports its errors again
+	const errSQL = `INSERT INTO import_job_errors(job_id, row_no, order_uid, error)
+                    VALUES ($1, $2, $3, $4)
+                    ON CONFLICT (job_id, row_no) DO NOTHING`
+
+	batch := &pgx.Batch{}
+	for _, e := range errs {
+		batch.Queue(errSQL, j.ID, e.Row, e.OrderUID, e.Error)
+	}
+	if batch.Len() > 0 {
+		if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *PgRepo) RequeueImportJob(ctx context.Context, id int64) (bool, error) {
+	const requeueSQL = `UPDATE import_jobs
+                        SET status=$2, finished_at=NULL, updated_at=now()
+                        WHERE job_id=$1 AND status=$3`
+
+	tag, err := p.pool.Exec(ctx, requeueSQL, id, domain.ImportPending, domain.ImportFailed)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_erasures_customer;
+DROP INDEX IF EXISTS idx_orders_customer;
+
+DROP TABLE IF EXISTS personal_data_erasures;
@@ -0,0 +1,10 @@
+-- Журнал удаления персональных данных по запросу клиента
+CREATE TABLE IF NOT EXISTS personal_data_erasures (
+    erasure_id       BIGSERIAL PRIMARY KEY,
+    customer_id      VARCHAR(64) NOT NULL,
+    orders_affected  INTEGER     NOT NULL,
+    erased_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_orders_customer ON orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_erasures_customer ON personal_data_erasures(customer_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_sub;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_outbox_webhooks_pending;
+
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS outbox;
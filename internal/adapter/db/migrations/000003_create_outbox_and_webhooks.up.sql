@@ -0,0 +1,42 @@
+-- Outbox: события пишутся в той же транзакции, что и заказ
+CREATE TABLE IF NOT EXISTS outbox (
+    outbox_id              BIGSERIAL   PRIMARY KEY,
+    order_uid              UUID        NOT NULL,
+    event_type             VARCHAR(64) NOT NULL,
+    payload                JSONB       NOT NULL,
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT now(),
+    webhooks_dispatched_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS idx_outbox_webhooks_pending ON outbox(outbox_id) WHERE webhooks_dispatched_at IS NULL;
+
+-- Подписки партнёров на события
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id BIGSERIAL   PRIMARY KEY,
+    url             TEXT        NOT NULL,
+    secret          TEXT        NOT NULL,
+    event_types     TEXT[]      NOT NULL DEFAULT '{}',
+    active          BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+-- Журнал доставок, он же очередь отправки
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id     BIGSERIAL   PRIMARY KEY,
+    subscription_id BIGINT      NOT NULL
+                    REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    outbox_id       BIGINT      NOT NULL
+                    REFERENCES outbox(outbox_id) ON DELETE CASCADE,
+    event_type      VARCHAR(64) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT,
+    response_code   INTEGER,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_sub ON webhook_deliveries(subscription_id, delivery_id);
@@ -0,0 +1,9 @@
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS webhook_deliveries_outbox_id_fkey;
+DELETE FROM webhook_deliveries WHERE outbox_id IS NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN outbox_id SET NOT NULL;
+ALTER TABLE webhook_deliveries ADD CONSTRAINT webhook_deliveries_outbox_id_fkey
+    FOREIGN KEY (outbox_id) REFERENCES outbox(outbox_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS published_at;
@@ -0,0 +1,10 @@
+-- Публикация outbox в Kafka (orders-events)
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(outbox_id) WHERE published_at IS NULL;
+
+-- Журнал доставок вебхуков должен переживать очистку outbox
+ALTER TABLE webhook_deliveries ALTER COLUMN outbox_id DROP NOT NULL;
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS webhook_deliveries_outbox_id_fkey;
+ALTER TABLE webhook_deliveries ADD CONSTRAINT webhook_deliveries_outbox_id_fkey
+    FOREIGN KEY (outbox_id) REFERENCES outbox(outbox_id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_status;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS status_updated_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+-- Статус заказа из топиков доставки и отмен
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status            VARCHAR(32) NOT NULL DEFAULT 'created';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_reason     TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
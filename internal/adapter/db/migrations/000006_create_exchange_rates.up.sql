@@ -0,0 +1,8 @@
+-- Курсы валют к базовой валюте (BASE_CURRENCY): сколько единиц базовой валюты стоит одна единица currency
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency   CHAR(3)        NOT NULL,
+    rate_date  DATE           NOT NULL,
+    rate       NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    loaded_at  TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    PRIMARY KEY (currency, rate_date)
+);
@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS analytics_items_daily;
+DROP MATERIALIZED VIEW IF EXISTS analytics_orders_daily;
@@ -0,0 +1,27 @@
+-- Задания массового импорта заказов из файлов
+CREATE TABLE IF NOT EXISTS import_jobs (
+    job_id      BIGSERIAL   PRIMARY KEY,
+    source      TEXT        NOT NULL,
+    format      VARCHAR(16) NOT NULL,
+    dry_run     BOOLEAN     NOT NULL DEFAULT FALSE,
+    status      VARCHAR(16) NOT NULL DEFAULT 'pending',
+    -- строк файла обработано, с этой позиции задание продолжается после сбоя
+    rows_done   BIGINT      NOT NULL DEFAULT 0,
+    orders_ok   BIGINT      NOT NULL DEFAULT 0,
+    orders_bad  BIGINT      NOT NULL DEFAULT 0,
+    last_error  TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+    );
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_active ON import_jobs(updated_at) WHERE status IN ('pending', 'running');
+
+-- Ошибки по строкам, строка — первая строка заказа в файле
+CREATE TABLE IF NOT EXISTS import_job_errors (
+    job_id    BIGINT NOT NULL REFERENCES import_jobs(job_id) ON DELETE CASCADE,
+    row_no    BIGINT NOT NULL,
+    order_uid TEXT   NOT NULL DEFAULT '',
+    error     TEXT   NOT NULL,
+    PRIMARY KEY (job_id, row_no)
+    );
@@ -0,0 +1,2 @@
+-- Заказы, уже сохранённые ранее, импорт пропускает и считает отдельно
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS orders_skipped BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,58 @@
+-- Возврат к двум знакам, третий знак сумм в KWD, BHD и т.п. округляется
+-- Материализованные представления зависят от этих колонок, поэтому пересоздаются
+DROP MATERIALIZED VIEW IF EXISTS analytics_items_daily;
+DROP MATERIALIZED VIEW IF EXISTS analytics_orders_daily;
+
+ALTER TABLE payments
+    ALTER COLUMN amount        TYPE NUMERIC(12,2),
+    ALTER COLUMN delivery_cost TYPE NUMERIC(12,2),
+    ALTER COLUMN goods_total   TYPE NUMERIC(12,2),
+    ALTER COLUMN custom_fee    TYPE NUMERIC(12,2);
+ALTER TABLE items
+    ALTER COLUMN price       TYPE NUMERIC(12,2),
+    ALTER COLUMN total_price TYPE NUMERIC(12,2);
+
+-- Дневные агрегаты заказов для /analytics, суммы в валюте заказа.
+-- Отменённые заказы не учитываются, пустые измерения хранятся как '' ради уникального индекса
+CREATE MATERIALIZED VIEW IF NOT EXISTS analytics_orders_daily AS
+SELECT (o.date_created AT TIME ZONE 'UTC')::date AS day,
+       COALESCE(o.delivery_service, '')          AS delivery_service,
+       COALESCE(p.provider, '')                  AS provider,
+       COALESCE(p.bank, '')                      AS bank,
+       COALESCE(o.locale, '')                    AS locale,
+       COALESCE(p.currency, '')                  AS currency,
+       count(*)                                  AS orders,
+       COALESCE(sum(p.amount), 0)                AS revenue,
+       COALESCE(sum(p.goods_total), 0)           AS goods_total,
+       COALESCE(sum(p.delivery_cost), 0)         AS delivery_cost,
+       COALESCE(sum(i.items), 0)                 AS items,
+       COALESCE(sum(i.sale_items), 0)            AS sale_items
+FROM orders o
+JOIN payments p ON p.order_uid = o.order_uid
+LEFT JOIN LATERAL (SELECT count(*)                        AS items,
+                          count(*) FILTER (WHERE sale > 0) AS sale_items
+                   FROM items WHERE items.order_uid = o.order_uid) i ON true
+WHERE o.status <> 'cancelled'
+GROUP BY 1, 2, 3, 4, 5, 6;
+
+-- уникальный индекс нужен для REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_orders_daily
+    ON analytics_orders_daily(day, delivery_service, provider, bank, locale, currency);
+
+-- Дневная выручка по брендам и артикулам
+CREATE MATERIALIZED VIEW IF NOT EXISTS analytics_items_daily AS
+SELECT (o.date_created AT TIME ZONE 'UTC')::date AS day,
+       COALESCE(p.currency, '')                  AS currency,
+       COALESCE(i.brand, '')                     AS brand,
+       COALESCE(i.nm_id, 0)                      AS nm_id,
+       count(*)                                  AS units,
+       count(*) FILTER (WHERE i.sale > 0)        AS sale_units,
+       COALESCE(sum(i.total_price), 0)           AS revenue
+FROM items i
+JOIN orders o   ON o.order_uid = i.order_uid
+JOIN payments p ON p.order_uid = i.order_uid
+WHERE o.status <> 'cancelled'
+GROUP BY 1, 2, 3, 4;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_analytics_items_daily
+    ON analytics_items_daily(day, currency, brand, nm_id);
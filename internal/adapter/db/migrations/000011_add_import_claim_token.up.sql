@@ -0,0 +1,3 @@
+-- Токен запуска, взявшего задание: прогресс сохраняет только он, воркер,
+-- у которого задание забрали по истечении аренды, останавливается
+ALTER TABLE import_jobs ADD COLUMN IF NOT EXISTS claim_token UUID;
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"order-service/internal/domain"
+	"time"
+)
+
+const (
+	outboxChannel = "outbox_events"
+	// relayLockKey is the advisory lock taken by the instance currently relaying the outbox.
+	relayLockKey = 0x6f7574626f78
+)
+
+func (p *PgRepo) RelayOutbox(ctx context.Context, limit int, publish func([]*domain.OutboxEvent) error) (int, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err = tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, int64(relayLockKey)).Scan(&locked); err != nil {
+		return 0, err
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	const outboxSQL = `SELECT outbox_id, order_uid, event_type, payload, created_at
+                       FROM outbox WHERE published_at IS NULL
+                       ORDER BY outbox_id LIMIT $1`
+
+	rows, err := tx.Query(ctx, outboxSQL, limit)
+	if err != nil {
+		return 0, err
+	}
+	events := make([]*domain.OutboxEvent, 0, limit)
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var ev domain.OutboxEvent
+		if err = rows.Scan(&ev.ID, &ev.OrderUID, &ev.EventType, &ev.Payload, &ev.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, &ev)
+		ids = append(ids, ev.ID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err = publish(events); err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE outbox SET published_at=now() WHERE outbox_id = ANY($1)`, ids); err != nil {
+		return 0, err
+	}
+
+	return len(events), tx.Commit(ctx)
+}
+
+func (p *PgRepo) ListenOutbox(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	go func() {
+		defer close(ch)
+		for ctx.Err() == nil {
+			if err := p.listen(ctx, ch); err != nil && ctx.Err() == nil {
+				log.Printf("outbox listen: %v, reconnecting", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (p *PgRepo) listen(ctx context.Context, ch chan<- struct{}) error {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.Exec(context.Background(), "UNLISTEN "+outboxChannel)
+	}()
+
+	for {
+		if _, err = conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *PgRepo) CleanupOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	const cleanupSQL = `DELETE FROM outbox
+                        WHERE published_at < now() - $1::interval
+                          AND webhooks_dispatched_at IS NOT NULL`
+
+	tag, err := p.pool.Exec(ctx, cleanupSQL, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (p *PgRepo) OldestUnpublished(ctx context.Context) (time.Duration, error) {
+	var created time.Time
+	err := p.pool.QueryRow(ctx, `SELECT created_at FROM outbox WHERE published_at IS NULL ORDER BY outbox_id LIMIT 1`).Scan(&created)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(created), nil
+}
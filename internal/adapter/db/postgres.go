@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -34,10 +35,10 @@ func (p *PgRepo) Find(ctx context.Context, id string) (*domain.Order, error) {
 	log.Printf("Looking for order %s in database", uuid)
 
 	const orderSQL = `SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, 
-                  delivery_service, shardkey, sm_id, date_created 
+                  delivery_service, shardkey, sm_id, date_created, status 
                   FROM orders WHERE order_uid=$1`
 
-	err = p.pool.QueryRow(ctx, orderSQL, uuid).Scan(&order.OrderId, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerId, &order.DeliveryService, &order.ShardKey, &order.SmId, &order.DateCreated)
+	err = p.pool.QueryRow(ctx, orderSQL, uuid).Scan(&order.OrderId, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature, &order.CustomerId, &order.DeliveryService, &order.ShardKey, &order.SmId, &order.DateCreated, &order.Status)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -93,6 +94,10 @@ func (p *PgRepo) Find(ctx context.Context, id string) (*domain.Order, error) {
 		}
 		order.Items = append(order.Items, item)
 	}
+	if err := order.BindCurrency(); err != nil {
+		log.Printf("Error reading amounts of order %s: %v", uuid, err)
+		return nil, err
+	}
 
 	log.Printf("Successfully loaded order %s with %d items", uuid, len(order.Items))
 	return &order, nil
@@ -159,35 +164,208 @@ VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 		return err
 	}
 
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	const outboxSQL = `INSERT INTO outbox(order_uid, event_type, payload) VALUES ($1, $2, $3)`
+
+	if _, err = tx.Exec(ctx, outboxSQL, order.OrderId, domain.EventOrderCreated, payload); err != nil {
+		return err
+	}
+
+	// delivered to listeners only if the transaction commits
+	if _, err = tx.Exec(ctx, `SELECT pg_notify($1, '')`, outboxChannel); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
 func (p *PgRepo) CacheRestore(ctx context.Context) ([]*domain.Order, error) {
-	result := make([]*domain.Order, 0, 10)
-	const orderSQL = `SELECT order_uid FROM orders ORDER BY date_created DESC LIMIT 10 `
+	return p.FindRecent(ctx, 10)
+}
 
-	rows, err := p.pool.Query(ctx, orderSQL)
+func (p *PgRepo) FindRecent(ctx context.Context, limit int) ([]*domain.Order, error) {
+	result := make([]*domain.Order, 0, limit)
+	const orderSQL = `SELECT order_uid FROM orders ORDER BY date_created DESC LIMIT $1`
 
+	rows, err := p.pool.Query(ctx, orderSQL, limit)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	ids := make([]string, 0, limit)
 	for rows.Next() {
 		var orderUID string
 		if err = rows.Scan(&orderUID); err != nil {
+			rows.Close()
 			return nil, err
 		}
+		ids = append(ids, orderUID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
-		order, err := p.Find(ctx, orderUID)
+	for _, id := range ids {
+		order, err := p.Find(ctx, id)
 		if err != nil {
 			return nil, err
 		}
+		if order != nil {
+			result = append(result, order)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *PgRepo) FindRecentByCustomer(ctx context.Context, customerID string, limit int) ([]*domain.Order, error) {
+	const orderSQL = `SELECT order_uid FROM orders WHERE customer_id=$1 ORDER BY date_created DESC LIMIT $2`
+
+	rows, err := p.pool.Query(ctx, orderSQL, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
 
-		result = append(result, order)
+	ids := make([]string, 0, limit)
+	for rows.Next() {
+		var orderUID string
+		if err = rows.Scan(&orderUID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, orderUID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
+	result := make([]*domain.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := p.Find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if order != nil {
+			result = append(result, order)
+		}
+	}
 	return result, nil
+}
+
+func (p *PgRepo) FindByCustomer(ctx context.Context, customerID string) ([]*domain.Order, error) {
+	const orderSQL = `SELECT order_uid FROM orders WHERE customer_id=$1 ORDER BY date_created`
+
+	rows, err := p.pool.Query(ctx, orderSQL, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return nil, nil
+	ids := make([]string, 0)
+	for rows.Next() {
+		var orderUID string
+		if err = rows.Scan(&orderUID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, orderUID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := p.Find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if order != nil {
+			result = append(result, order)
+		}
+	}
+
+	log.Printf("Found %d orders for customer %s", len(result), customerID)
+	return result, nil
+}
+
+func (p *PgRepo) ErasePersonalData(ctx context.Context, customerID string) ([]string, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var erased domain.Delivery
+	erased.Erase()
+
+	const delSQL = `UPDATE deliveries d SET del_name=$2, phone=$3, zip=$4, address=$5, email=$6
+                    FROM orders o
+                    WHERE d.order_uid=o.order_uid AND o.customer_id=$1
+                    RETURNING d.order_uid`
+
+	rows, err := tx.Query(ctx, delSQL, customerID, erased.Name, erased.Phone, erased.Zip, erased.Address, erased.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var orderUID string
+		if err = rows.Scan(&orderUID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, orderUID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// events already written carry the order as it was, the outbox rows and
+	// the webhook deliveries made from them get the same erased fields
+	patch, err := json.Marshal(map[string]string{
+		"name": erased.Name, "phone": erased.Phone, "zip": erased.Zip, "address": erased.Address, "email": erased.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	const outboxSQL = `UPDATE outbox SET payload = jsonb_set(payload, '{delivery}', payload->'delivery' || $2::jsonb)
+                       WHERE order_uid = ANY($1::uuid[]) AND payload ? 'delivery'`
+
+	if _, err = tx.Exec(ctx, outboxSQL, ids, patch); err != nil {
+		return nil, err
+	}
+
+	const webhookSQL = `UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{data,delivery}', payload->'data'->'delivery' || $2::jsonb)
+                        WHERE payload->'data'->>'order_uid' = ANY($1::text[]) AND payload->'data' ? 'delivery'`
+
+	if _, err = tx.Exec(ctx, webhookSQL, ids, patch); err != nil {
+		return nil, err
+	}
+
+	const auditSQL = `INSERT INTO personal_data_erasures(customer_id, orders_affected) VALUES ($1, $2)`
+
+	if _, err = tx.Exec(ctx, auditSQL, customerID, len(ids)); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Erased personal data of customer %s in %d orders", customerID, len(ids))
+	return ids, nil
+}
 
+// Ping checks that the database accepts connections.
+func (p *PgRepo) Ping(ctx context.Context) error {
+	return p.pool.Ping(ctx)
 }
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math/big"
+	"order-service/internal/domain"
+	"time"
+)
+
+// rateScale matches exchange_rates.rate NUMERIC(20,10).
+const rateScale = 10
+
+func (p *PgRepo) SaveRates(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	const rateSQL = `INSERT INTO exchange_rates (currency, rate_date, rate) VALUES ($1, $2, $3)
+                     ON CONFLICT (currency, rate_date) DO UPDATE SET rate=EXCLUDED.rate, loaded_at=now()`
+
+	batch := &pgx.Batch{}
+	for _, r := range rates {
+		batch.Queue(rateSQL, r.Currency, r.Date, ratNumeric(r.Rate))
+	}
+	if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+	return len(rates), tx.Commit(ctx)
+}
+
+func (p *PgRepo) FindRate(ctx context.Context, currency string, on time.Time) (*domain.ExchangeRate, error) {
+	const rateSQL = `SELECT rate_date, rate FROM exchange_rates
+                     WHERE currency=$1 AND rate_date <= $2
+                     ORDER BY rate_date DESC LIMIT 1`
+
+	r := domain.ExchangeRate{Currency: currency}
+	var n pgtype.Numeric
+	err := p.pool.QueryRow(ctx, rateSQL, currency, on).Scan(&r.Date, &n)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.Rate = numericRat(n)
+	return &r, nil
+}
+
+func ratNumeric(r *big.Rat) pgtype.Numeric {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(rateScale), nil)))
+	return pgtype.Numeric{Int: new(big.Int).Quo(scaled.Num(), scaled.Denom()), Exp: -rateScale, Valid: true}
+}
+
+func numericRat(n pgtype.Numeric) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(n.Exp, -n.Exp))), nil)
+	if n.Exp < 0 {
+		return new(big.Rat).SetFrac(n.Int, scale)
+	}
+	return new(big.Rat).SetInt(new(big.Int).Mul(n.Int, scale))
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"order-service/internal/domain"
+)
+
+// ConfirmPayment overwrites the payment of a stored order with the settled one.
+// It reports false when the order is unknown.
+func (p *PgRepo) ConfirmPayment(ctx context.Context, c domain.PaymentConfirmation) (bool, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	const paySQL = `UPDATE payments SET transaction_id=$2, request_id=$3, currency=$4, provider=$5,
+                    amount=$6, payment_dt=$7, bank=$8
+                    WHERE order_uid=$1`
+
+	tag, err := tx.Exec(ctx, paySQL, c.OrderUID, c.TransactionId, c.RequestId, c.Currency,
+		c.Provider, c.Amount, c.PaymentDt, c.Bank)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err = writeOutbox(ctx, tx, c.OrderUID.String(), domain.EventPaymentConfirmed, c); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// UpdateStatus sets the order status unless a newer update is already stored.
+// It reports false when the order is unknown or the update is stale.
+func (p *PgRepo) UpdateStatus(ctx context.Context, u domain.StatusUpdate) (bool, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	const statusSQL = `UPDATE orders SET status=$2, status_reason=NULLIF($3, ''), status_updated_at=$4
+                       WHERE order_uid=$1 AND (status_updated_at IS NULL OR status_updated_at < $4)`
+
+	tag, err := tx.Exec(ctx, statusSQL, u.OrderUID, u.Status, u.Reason, u.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err = writeOutbox(ctx, tx, u.OrderUID.String(), domain.EventOrderStatusChanged, u); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+func writeOutbox(ctx context.Context, tx pgx.Tx, orderUID, event string, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	const outboxSQL = `INSERT INTO outbox(order_uid, event_type, payload) VALUES ($1, $2, $3)`
+
+	if _, err = tx.Exec(ctx, outboxSQL, orderUID, event, payload); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `SELECT pg_notify($1, '')`, outboxChannel)
+	return err
+}
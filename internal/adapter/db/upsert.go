@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"order-service/internal/domain"
+)
+
+// erasedSQL finds erasures of the customer made after the order was created,
+// orders placed after an erasure keep their delivery.
+const erasedSQL = `SELECT EXISTS(SELECT 1 FROM personal_data_erasures WHERE customer_id=$1 AND erased_at >= $2)`
+
+// PersonalDataErased reports whether the delivery of order was erased on
+// request of its customer.
+func (p *PgRepo) PersonalDataErased(ctx context.Context, order *domain.Order) (bool, error) {
+	var erased bool
+	err := p.pool.QueryRow(ctx, erasedSQL, order.CustomerId, order.DateCreated).Scan(&erased)
+	return erased, err
+}
+
+// Upsert stores the order whether it exists or not. Items are replaced as a
+// whole, they have no natural key to merge on. It reports whether the order was new.
+// Delivery PII of orders erased on request of the customer is erased again
+// in order itself, a replay must not bring it back.
+func (p *PgRepo) Upsert(ctx context.Context, order *domain.Order) (bool, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	const orderSQL = `INSERT INTO orders(order_uid, track_number, entry, locale, internal_signature,
+                      customer_id, delivery_service, shardkey, sm_id, date_created)
+                      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+                      ON CONFLICT (order_uid) DO UPDATE SET
+                          track_number=EXCLUDED.track_number, entry=EXCLUDED.entry, locale=EXCLUDED.locale,
+                          internal_signature=EXCLUDED.internal_signature, customer_id=EXCLUDED.customer_id,
+                          delivery_service=EXCLUDED.delivery_service, shardkey=EXCLUDED.shardkey,
+                          sm_id=EXCLUDED.sm_id, date_created=EXCLUDED.date_created
+                      RETURNING (xmax = 0)`
+
+	var inserted bool
+	err = tx.QueryRow(ctx, orderSQL, order.OrderId, order.TrackNumber,
+		order.Entry, order.Locale, order.InternalSignature, order.CustomerId,
+		order.DeliveryService, order.ShardKey, order.SmId, order.DateCreated).Scan(&inserted)
+	if err != nil {
+		return false, err
+	}
+
+	var erased bool
+	if err = tx.QueryRow(ctx, erasedSQL, order.CustomerId, order.DateCreated).Scan(&erased); err != nil {
+		return false, err
+	}
+	if erased {
+		order.Delivery.Erase()
+	}
+
+	const delSQL = `INSERT INTO deliveries(order_uid, del_name, phone, zip, city, address, region, email)
+                    VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+                    ON CONFLICT (order_uid) DO UPDATE SET
+                        del_name=EXCLUDED.del_name, phone=EXCLUDED.phone, zip=EXCLUDED.zip, city=EXCLUDED.city,
+                        address=EXCLUDED.address, region=EXCLUDED.region, email=EXCLUDED.email`
+
+	_, err = tx.Exec(ctx, delSQL, order.OrderId, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+		order.Delivery.City, order.Delivery.Address,
+		order.Delivery.Region, order.Delivery.Email)
+	if err != nil {
+		return false, err
+	}
+
+	const paySQL = `INSERT INTO payments(order_uid, transaction_id, request_id, currency, provider,
+                    amount, payment_dt, bank, delivery_cost, goods_total, custom_fee)
+                    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+                    ON CONFLICT (order_uid) DO UPDATE SET
+                        transaction_id=EXCLUDED.transaction_id, request_id=EXCLUDED.request_id,
+                        currency=EXCLUDED.currency, provider=EXCLUDED.provider, amount=EXCLUDED.amount,
+                        payment_dt=EXCLUDED.payment_dt, bank=EXCLUDED.bank, delivery_cost=EXCLUDED.delivery_cost,
+                        goods_total=EXCLUDED.goods_total, custom_fee=EXCLUDED.custom_fee`
+
+	_, err = tx.Exec(ctx, paySQL, order.OrderId, order.Payment.TransactionId, order.Payment.RequestId,
+		order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
+		order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM items WHERE order_uid=$1`, order.OrderId); err != nil {
+		return false, err
+	}
+
+	const itemSQL = `INSERT INTO items(order_uid, chrt_id, track_number, price, rid,
+                     item_name, sale, item_size, total_price, nm_id, brand, status)
+                     VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	batch := &pgx.Batch{}
+	for _, item := range order.Items {
+		batch.Queue(itemSQL, order.OrderId, item.ChrtId, item.TrackNumber, item.Price, item.RID, item.Name, item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status)
+	}
+	if err = tx.SendBatch(ctx, batch).Close(); err != nil {
+		return false, err
+	}
+
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return false, err
+	}
+	event := domain.EventOrderUpdated
+	if inserted {
+		event = domain.EventOrderCreated
+	}
+
+	const outboxSQL = `INSERT INTO outbox(order_uid, event_type, payload) VALUES ($1, $2, $3)`
+
+	if _, err = tx.Exec(ctx, outboxSQL, order.OrderId, event, payload); err != nil {
+		return false, err
+	}
+	if _, err = tx.Exec(ctx, `SELECT pg_notify($1, '')`, outboxChannel); err != nil {
+		return false, err
+	}
+
+	return inserted, tx.Commit(ctx)
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"order-service/internal/domain"
+	"time"
+)
+
+func (p *PgRepo) CreateSubscription(ctx context.Context, s *domain.WebhookSubscription) error {
+	const subSQL = `INSERT INTO webhook_subscriptions(url, secret, event_types, active)
+                    VALUES ($1, $2, $3, $4)
+                    RETURNING subscription_id, created_at`
+
+	if s.EventTypes == nil {
+		s.EventTypes = []string{}
+	}
+	return p.pool.QueryRow(ctx, subSQL, s.URL, s.Secret, s.EventTypes, s.Active).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (p *PgRepo) ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	const subSQL = `SELECT subscription_id, url, event_types, active, created_at
+                    FROM webhook_subscriptions ORDER BY subscription_id`
+
+	rows, err := p.pool.Query(ctx, subSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.WebhookSubscription, 0)
+	for rows.Next() {
+		var s domain.WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.URL, &s.EventTypes, &s.Active, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &s)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgRepo) DeleteSubscription(ctx context.Context, id int64) (bool, error) {
+	tag, err := p.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE subscription_id=$1`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *PgRepo) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	const delSQL = `SELECT delivery_id, subscription_id, event_type, payload, status, attempts, next_attempt_at,
+                    COALESCE(last_error, ''), COALESCE(response_code, 0), created_at, updated_at
+                    FROM webhook_deliveries WHERE subscription_id=$1
+                    ORDER BY delivery_id DESC LIMIT $2`
+
+	rows, err := p.pool.Query(ctx, delSQL, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.ResponseCode, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &d)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgRepo) ReplayDelivery(ctx context.Context, id int64) (bool, error) {
+	const replaySQL = `UPDATE webhook_deliveries
+                       SET status=$2, attempts=0, next_attempt_at=now(), updated_at=now()
+                       WHERE delivery_id=$1`
+
+	tag, err := p.pool.Exec(ctx, replaySQL, id, domain.DeliveryPending)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (p *PgRepo) DispatchOutbox(ctx context.Context, limit int) (int, error) {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	const claimSQL = `SELECT outbox_id FROM outbox
+                      WHERE webhooks_dispatched_at IS NULL
+                      ORDER BY outbox_id LIMIT $1
+                      FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.Query(ctx, claimSQL, limit)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	const fanoutSQL = `INSERT INTO webhook_deliveries(subscription_id, outbox_id, event_type, payload)
+                       SELECT s.subscription_id, o.outbox_id, o.event_type,
+                              jsonb_build_object('id', o.outbox_id, 'type', o.event_type,
+                                                 'occurred_at', o.created_at, 'data', o.payload)
+                       FROM outbox o
+                       JOIN webhook_subscriptions s
+                         ON s.active AND (cardinality(s.event_types) = 0 OR o.event_type = ANY(s.event_types))
+                       WHERE o.outbox_id = ANY($1)`
+
+	if _, err = tx.Exec(ctx, fanoutSQL, ids); err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE outbox SET webhooks_dispatched_at=now() WHERE outbox_id = ANY($1)`, ids); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	log.Printf("Dispatched %d outbox events to webhooks", len(ids))
+	return len(ids), nil
+}
+
+func (p *PgRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	const claimSQL = `UPDATE webhook_deliveries d
+                      SET next_attempt_at = now() + $3::interval
+                      FROM webhook_subscriptions s
+                      WHERE d.subscription_id = s.subscription_id
+                        AND d.delivery_id IN (
+                            SELECT delivery_id FROM webhook_deliveries
+                            WHERE status=$1 AND next_attempt_at <= now()
+                            ORDER BY next_attempt_at LIMIT $2
+                            FOR UPDATE SKIP LOCKED)
+                      RETURNING d.delivery_id, d.subscription_id, s.url, s.secret, d.event_type, d.payload,
+                                d.status, d.attempts, d.created_at`
+
+	rows, err := p.pool.Query(ctx, claimSQL, domain.DeliveryPending, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.URL, &d.Secret, &d.EventType, &d.Payload,
+			&d.Status, &d.Attempts, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, &d)
+	}
+	return result, rows.Err()
+}
+
+func (p *PgRepo) CompleteDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	const doneSQL = `UPDATE webhook_deliveries
+                     SET status=$2, attempts=$3, next_attempt_at=$4, last_error=NULLIF($5, ''),
+                         response_code=NULLIF($6, 0), updated_at=now()
+                     WHERE delivery_id=$1`
+
+	_, err := p.pool.Exec(ctx, doneSQL, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseCode)
+	return err
+}
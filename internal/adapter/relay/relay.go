@@ -0,0 +1,154 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"order-service/internal/domain"
+	"order-service/internal/kafkaconn"
+	"order-service/internal/metrics"
+	"order-service/internal/repository"
+	"strconv"
+	"time"
+)
+
+// canonical event names published on the events topic
+var eventNames = map[string]string{
+	domain.EventOrderCreated:       "OrderCreated",
+	domain.EventOrderUpdated:       "OrderUpdated",
+	domain.EventOrderStatusChanged: "OrderStatusChanged",
+	domain.EventPaymentConfirmed:   "PaymentConfirmed",
+}
+
+type Config struct {
+	Conn  *kafkaconn.Conn
+	Topic string
+
+	PollInterval    time.Duration
+	BatchSize       int
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+// Event is the message value published for every outbox row.
+type Event struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	OrderUID   string          `json:"order_uid"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Order      json.RawMessage `json:"order"`
+}
+
+// Relay publishes committed outbox events to Kafka keyed by order_uid,
+// so events of one order land on one partition in commit order.
+type Relay struct {
+	repo repository.OutboxRepository
+	w    *kafka.Writer
+	cfg  Config
+}
+
+func New(r repository.OutboxRepository, cfg Config) *Relay {
+	w := cfg.Conn.Writer(cfg.Topic)
+	w.Balancer = &kafka.Hash{}
+	return &Relay{
+		repo: r,
+		w:    w,
+		cfg:  cfg,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	defer r.w.Close()
+	log.Printf("outbox relay → %s, polling every %s", r.cfg.Topic, r.cfg.PollInterval)
+
+	notify := r.repo.ListenOutbox(ctx)
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		case <-poll.C:
+		case <-cleanup.C:
+			n, err := r.repo.CleanupOutbox(ctx, r.cfg.Retention)
+			if err != nil {
+				log.Printf("outbox cleanup: %v", err)
+			} else if n > 0 {
+				log.Printf("outbox cleanup: removed %d events", n)
+			}
+			continue
+		}
+
+		r.drain(ctx)
+
+		if lag, err := r.repo.OldestUnpublished(ctx); err == nil {
+			metrics.RelayLag.Set(lag.Seconds())
+		}
+	}
+}
+
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		start := time.Now()
+		n, err := r.repo.RelayOutbox(ctx, r.cfg.BatchSize, func(events []*domain.OutboxEvent) error {
+			return r.publish(ctx, events)
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("outbox relay: %v", err)
+				metrics.RelayErrors.Inc()
+			}
+			return
+		}
+		if n == 0 {
+			return
+		}
+
+		metrics.RelayPublished.Add(float64(n))
+		metrics.RelayBatchSeconds.Observe(time.Since(start).Seconds())
+		log.Printf("outbox relay: published %d events", n)
+
+		if n < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, events []*domain.OutboxEvent) error {
+	msgs := make([]kafka.Message, 0, len(events))
+	for _, ev := range events {
+		name, ok := eventNames[ev.EventType]
+		if !ok {
+			name = ev.EventType
+		}
+		id := strconv.FormatInt(ev.ID, 10)
+
+		value, err := json.Marshal(Event{
+			EventID:    id,
+			EventType:  name,
+			OrderUID:   ev.OrderUID.String(),
+			OccurredAt: ev.CreatedAt,
+			Order:      ev.Payload,
+		})
+		if err != nil {
+			return err
+		}
+
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(ev.OrderUID.String()),
+			Value: value,
+			Time:  ev.CreatedAt,
+			Headers: []kafka.Header{
+				{Key: "event-type", Value: []byte(name)},
+				{Key: "event-id", Value: []byte(id)},
+			},
+		})
+	}
+
+	return r.w.WriteMessages(ctx, msgs...)
+}
@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"order-service/internal/domain"
+	"order-service/internal/metrics"
+	"order-service/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Concurrency  int
+	Timeout      time.Duration
+
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// BreakerThreshold consecutive failures open the endpoint's breaker for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type Sender struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	cfg    Config
+
+	mu       sync.Mutex
+	breakers map[int64]*breaker
+}
+
+// breaker is a per-subscription circuit breaker. While open, deliveries to the
+// endpoint are postponed without spending attempts.
+type breaker struct {
+	failures  int
+	openUntil time.Time
+}
+
+// NewSender fills zero and negative settings of cfg with defaults.
+func NewSender(r repository.WebhookRepository, cfg Config) *Sender {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 50
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	if cfg.BreakerThreshold < 1 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = time.Minute
+	}
+
+	return &Sender{
+		repo:     r,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		cfg:      cfg,
+		breakers: make(map[int64]*breaker),
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", receivers
+// recompute it with their secret to authenticate the call.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *Sender) Run(ctx context.Context) error {
+	log.Printf("webhook sender started, polling every %s", s.cfg.PollInterval)
+
+	t := time.NewTicker(s.cfg.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		if _, err := s.repo.DispatchOutbox(ctx, s.cfg.BatchSize); err != nil && ctx.Err() == nil {
+			log.Printf("webhook dispatch: %v", err)
+		}
+
+		// lease covers the whole batch even if every call hits the timeout
+		lease := s.cfg.Timeout*time.Duration(s.cfg.BatchSize/s.cfg.Concurrency+1) + time.Minute
+		list, err := s.repo.ClaimDeliveries(ctx, s.cfg.BatchSize, lease)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("webhook claim: %v", err)
+			}
+			continue
+		}
+
+		sem := make(chan struct{}, s.cfg.Concurrency)
+		var wg sync.WaitGroup
+		for _, d := range list {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(d *domain.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.deliver(ctx, d)
+			}(d)
+		}
+		wg.Wait()
+	}
+}
+
+func (s *Sender) deliver(ctx context.Context, d *domain.WebhookDelivery) {
+	if until, open := s.open(d.SubscriptionID); open {
+		d.NextAttemptAt = until
+		d.LastError = "circuit open"
+		if err := s.repo.CompleteDelivery(ctx, d); err != nil {
+			log.Printf("webhook %d: save: %v", d.ID, err)
+		}
+		metrics.WebhookDeliveries.WithLabelValues("postponed").Inc()
+		return
+	}
+
+	d.Attempts++
+	code, err := s.post(ctx, d)
+	d.ResponseCode = code
+
+	if err == nil {
+		d.Status = domain.DeliverySucceeded
+		d.LastError = ""
+		d.NextAttemptAt = time.Now()
+		s.record(d.SubscriptionID, true)
+		metrics.WebhookDeliveries.WithLabelValues("succeeded").Inc()
+	} else {
+		d.LastError = err.Error()
+		s.record(d.SubscriptionID, false)
+		if d.Attempts >= s.cfg.MaxAttempts {
+			d.Status = domain.DeliveryFailed
+			d.NextAttemptAt = time.Now()
+			log.Printf("webhook %d to %s failed for good after %d attempts: %v", d.ID, d.URL, d.Attempts, err)
+			metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		} else {
+			d.Status = domain.DeliveryPending
+			d.NextAttemptAt = time.Now().Add(s.backoff(d.Attempts))
+			log.Printf("webhook %d to %s attempt %d failed, retry at %s: %v", d.ID, d.URL, d.Attempts, d.NextAttemptAt.Format(time.RFC3339), err)
+			metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+		}
+	}
+
+	if err := s.repo.CompleteDelivery(ctx, d); err != nil {
+		log.Printf("webhook %d: save: %v", d.ID, err)
+	}
+}
+
+func (s *Sender) post(ctx context.Context, d *domain.WebhookDelivery) (int, error) {
+	ts := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, ts, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *Sender) backoff(attempt int) time.Duration {
+	d := s.cfg.BaseBackoff << (attempt - 1)
+	if d <= 0 || d > s.cfg.MaxBackoff {
+		return s.cfg.MaxBackoff
+	}
+	return d
+}
+
+func (s *Sender) open(subscriptionID int64) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.breakers[subscriptionID]
+	if !ok || time.Now().After(b.openUntil) {
+		return time.Time{}, false
+	}
+	return b.openUntil, true
+}
+
+func (s *Sender) record(subscriptionID int64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, found := s.breakers[subscriptionID]
+	if !found {
+		b = &breaker{}
+		s.breakers[subscriptionID] = b
+	}
+
+	if ok {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+
+	b.failures++
+	if b.failures >= s.cfg.BreakerThreshold {
+		b.openUntil = time.Now().Add(s.cfg.BreakerCooldown)
+		log.Printf("webhook subscription %d: circuit open until %s", subscriptionID, b.openUntil.Format(time.RFC3339))
+		metrics.WebhookBreakerOpened.Inc()
+	}
+}
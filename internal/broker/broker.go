@@ -0,0 +1,124 @@
+package broker
+
+import (
+	"context"
+	"log"
+	"order-service/internal/domain"
+	"order-service/internal/metrics"
+	"sync"
+	"time"
+)
+
+type Event struct {
+	ID    uint64
+	Type  string
+	Time  time.Time
+	Order *domain.Order
+}
+
+// Broker fans order events out to in-process subscribers and keeps
+// the last few of them so reconnecting clients can resume.
+type Broker struct {
+	mu     sync.Mutex
+	lastID uint64
+	replay []Event
+	size   int
+	buffer int
+	subs   map[*Subscription]struct{}
+}
+
+// Subscription delivers events on C. C is closed when the subscriber's
+// context is done or when it fell behind by more than its buffer.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	lagged bool
+}
+
+// Lagged reports whether the subscription was dropped for being too slow.
+// Only meaningful after C is closed.
+func (s *Subscription) Lagged() bool {
+	return s.lagged
+}
+
+func New(replaySize, buffer int) *Broker {
+	return &Broker{
+		replay: make([]Event, 0, replaySize),
+		size:   replaySize,
+		buffer: buffer,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) Publish(eventType string, order *domain.Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	ev := Event{ID: b.lastID, Type: eventType, Time: time.Now().UTC(), Order: order}
+
+	if b.size > 0 {
+		if len(b.replay) == b.size {
+			copy(b.replay, b.replay[1:])
+			b.replay = b.replay[:b.size-1]
+		}
+		b.replay = append(b.replay, ev)
+	}
+
+	for s := range b.subs {
+		select {
+		case s.ch <- ev:
+		default:
+			// never block publishers on a slow client, drop it and let it resume
+			log.Printf("[Broker] Subscriber is too slow, dropping it at event %d", ev.ID)
+			s.lagged = true
+			b.remove(s)
+			metrics.StreamDropped.Inc()
+		}
+	}
+}
+
+// Subscribe returns a subscription receiving events published after afterID.
+// Events still held in the replay buffer are delivered first, afterID == 0
+// means live events only.
+func (b *Broker) Subscribe(ctx context.Context, afterID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missed := make([]Event, 0)
+	if afterID > 0 && afterID < b.lastID {
+		for _, ev := range b.replay {
+			if ev.ID > afterID {
+				missed = append(missed, ev)
+			}
+		}
+	}
+
+	ch := make(chan Event, b.buffer+len(missed))
+	for _, ev := range missed {
+		ch <- ev
+	}
+
+	s := &Subscription{C: ch, ch: ch}
+	b.subs[s] = struct{}{}
+	metrics.StreamSubscribers.Inc()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		b.remove(s)
+		b.mu.Unlock()
+	}()
+
+	return s
+}
+
+// remove must be called with b.mu held.
+func (b *Broker) remove(s *Subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+	metrics.StreamSubscribers.Dec()
+}
@@ -0,0 +1,216 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/hamba/avro/v2"
+	orderv1 "order-service/api/order/v1"
+	"order-service/internal/domain"
+	"sync"
+	"time"
+)
+
+// AvroDecoder reads Avro orders in the schema registry wire format. The
+// writer schema comes from the registry and is resolved against our reader
+// schema, so fields added, removed or aliased upstream decode as Avro
+// schema evolution rules say.
+type AvroDecoder struct {
+	registry *RegistryClient
+	reader   avro.Schema
+
+	mu       sync.RWMutex
+	resolved map[int]avro.Schema
+}
+
+func NewAvroDecoder(registry *RegistryClient) (*AvroDecoder, error) {
+	reader, err := avro.Parse(orderv1.AvroSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &AvroDecoder{
+		registry: registry,
+		reader:   reader,
+		resolved: make(map[int]avro.Schema),
+	}, nil
+}
+
+func (d *AvroDecoder) Decode(ctx context.Context, data []byte) (*domain.Order, error) {
+	id, payload, err := splitWireFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := d.schema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var rec avroOrder
+	if err = avro.Unmarshal(schema, payload, &rec); err != nil {
+		return nil, err
+	}
+	return settle(rec.toDomain())
+}
+
+func (d *AvroDecoder) schema(ctx context.Context, id int) (avro.Schema, error) {
+	d.mu.RLock()
+	s, ok := d.resolved[id]
+	d.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	raw, err := d.registry.SchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := avro.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	s, err = avro.NewSchemaCompatibility().Resolve(d.reader, writer)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.resolved[id] = s
+	d.mu.Unlock()
+	return s, nil
+}
+
+type avroOrder struct {
+	OrderUid          string       `avro:"order_uid"`
+	TrackNumber       string       `avro:"track_number"`
+	Entry             string       `avro:"entry"`
+	Locale            string       `avro:"locale"`
+	InternalSignature string       `avro:"internal_signature"`
+	CustomerId        string       `avro:"customer_id"`
+	DeliveryService   string       `avro:"delivery_service"`
+	ShardKey          int64        `avro:"shardkey"`
+	SmId              int64        `avro:"sm_id"`
+	DateCreated       time.Time    `avro:"date_created"`
+	Delivery          avroDelivery `avro:"delivery"`
+	Payment           avroPayment  `avro:"payment"`
+	Items             []avroItem   `avro:"items"`
+}
+
+type avroDelivery struct {
+	Name    string `avro:"name"`
+	Phone   string `avro:"phone"`
+	Zip     string `avro:"zip"`
+	City    string `avro:"city"`
+	Address string `avro:"address"`
+	Region  string `avro:"region"`
+	Email   string `avro:"email"`
+}
+
+type avroPayment struct {
+	TransactionId string `avro:"transaction_id"`
+	RequestId     string `avro:"request_id"`
+	Currency      string `avro:"currency"`
+	Provider      string `avro:"provider"`
+	Amount        int64  `avro:"amount"`
+	PaymentDt     int64  `avro:"payment_dt"`
+	Bank          string `avro:"bank"`
+	DeliveryCost  int64  `avro:"delivery_cost"`
+	GoodsTotal    int64  `avro:"goods_total"`
+	CustomFee     int64  `avro:"custom_fee"`
+
+	AmountDecimal       string `avro:"amount_decimal"`
+	DeliveryCostDecimal string `avro:"delivery_cost_decimal"`
+	GoodsTotalDecimal   string `avro:"goods_total_decimal"`
+	CustomFeeDecimal    string `avro:"custom_fee_decimal"`
+}
+
+type avroItem struct {
+	ChrtId      int64  `avro:"chrt_id"`
+	TrackNumber string `avro:"track_number"`
+	Price       int64  `avro:"price"`
+	RID         string `avro:"rid"`
+	Name        string `avro:"name"`
+	Sale        int64  `avro:"sale"`
+	Size        string `avro:"size"`
+	TotalPrice  int64  `avro:"total_price"`
+	NmID        int64  `avro:"nm_id"`
+	Brand       string `avro:"brand"`
+	Status      string `avro:"status"`
+
+	PriceDecimal      string `avro:"price_decimal"`
+	TotalPriceDecimal string `avro:"total_price_decimal"`
+}
+
+// toDomain reads decimal amounts of the payment currency, or whole units
+// from writers that send none.
+func (p avroPayment) toDomain() (domain.Payment, error) {
+	pay := domain.Payment{
+		TransactionId: p.TransactionId,
+		RequestId:     p.RequestId,
+		Currency:      p.Currency,
+		Provider:      p.Provider,
+		PaymentDt:     p.PaymentDt,
+		Bank:          p.Bank,
+	}
+	var err error
+	if pay.Amount, err = amount("payment.amount", p.AmountDecimal, p.Amount, p.Currency); err != nil {
+		return pay, err
+	}
+	if pay.DeliveryCost, err = amount("payment.delivery_cost", p.DeliveryCostDecimal, p.DeliveryCost, p.Currency); err != nil {
+		return pay, err
+	}
+	if pay.GoodsTotal, err = amount("payment.goods_total", p.GoodsTotalDecimal, p.GoodsTotal, p.Currency); err != nil {
+		return pay, err
+	}
+	pay.CustomFee, err = amount("payment.custom_fee", p.CustomFeeDecimal, p.CustomFee, p.Currency)
+	return pay, err
+}
+
+func (a *avroOrder) toDomain() (*domain.Order, error) {
+	id, err := uuid.Parse(a.OrderUid)
+	if err != nil {
+		return nil, err
+	}
+	pay, err := a.Payment.toDomain()
+	if err != nil {
+		return nil, err
+	}
+
+	o := &domain.Order{
+		OrderId:           id,
+		TrackNumber:       a.TrackNumber,
+		Entry:             a.Entry,
+		Locale:            a.Locale,
+		InternalSignature: a.InternalSignature,
+		CustomerId:        a.CustomerId,
+		DeliveryService:   a.DeliveryService,
+		ShardKey:          a.ShardKey,
+		SmId:              int(a.SmId),
+		DateCreated:       a.DateCreated,
+		Delivery:          domain.Delivery(a.Delivery),
+		Payment:           pay,
+		Items:             make([]domain.Item, 0, len(a.Items)),
+	}
+
+	for i, it := range a.Items {
+		item := domain.Item{
+			ChrtId:      it.ChrtId,
+			TrackNumber: it.TrackNumber,
+			RID:         it.RID,
+			Name:        it.Name,
+			Sale:        int(it.Sale),
+			Size:        it.Size,
+			NmID:        it.NmID,
+			Brand:       it.Brand,
+			Status:      it.Status,
+		}
+		if item.Price, err = amount(fmt.Sprintf("items[%d].price", i), it.PriceDecimal, it.Price, pay.Currency); err != nil {
+			return nil, err
+		}
+		if item.TotalPrice, err = amount(fmt.Sprintf("items[%d].total_price", i), it.TotalPriceDecimal, it.TotalPrice, pay.Currency); err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, item)
+	}
+	return o, nil
+}
@@ -0,0 +1,227 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hamba/avro/v2"
+	"net/http"
+	"net/http/httptest"
+	orderv1 "order-service/api/order/v1"
+	"order-service/internal/domain"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// registryServer serves schemas by id and counts the requests it got.
+func registryServer(t *testing.T, schemas map[int]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		for id, schema := range schemas {
+			if r.URL.Path == "/schemas/ids/"+strconv.Itoa(id) {
+				json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+				return
+			}
+		}
+		http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func toAvro(o *domain.Order) avroOrder {
+	a := avroOrder{
+		OrderUid:          o.OrderId.String(),
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerId,
+		DeliveryService:   o.DeliveryService,
+		ShardKey:          o.ShardKey,
+		SmId:              int64(o.SmId),
+		DateCreated:       o.DateCreated,
+		Delivery:          avroDelivery(o.Delivery),
+		Payment: avroPayment{
+			TransactionId: o.Payment.TransactionId,
+			RequestId:     o.Payment.RequestId,
+			Currency:      o.Payment.Currency,
+			Provider:      o.Payment.Provider,
+			Amount:        o.Payment.Amount.Major(),
+			PaymentDt:     o.Payment.PaymentDt,
+			Bank:          o.Payment.Bank,
+			DeliveryCost:  o.Payment.DeliveryCost.Major(),
+			GoodsTotal:    o.Payment.GoodsTotal.Major(),
+			CustomFee:     o.Payment.CustomFee.Major(),
+
+			AmountDecimal:       o.Payment.Amount.String(),
+			DeliveryCostDecimal: o.Payment.DeliveryCost.String(),
+			GoodsTotalDecimal:   o.Payment.GoodsTotal.String(),
+			CustomFeeDecimal:    o.Payment.CustomFee.String(),
+		},
+	}
+	for _, it := range o.Items {
+		a.Items = append(a.Items, avroItem{
+			ChrtId:      it.ChrtId,
+			TrackNumber: it.TrackNumber,
+			Price:       it.Price.Major(),
+			RID:         it.RID,
+			Name:        it.Name,
+			Sale:        int64(it.Sale),
+			Size:        it.Size,
+			TotalPrice:  it.TotalPrice.Major(),
+			NmID:        it.NmID,
+			Brand:       it.Brand,
+			Status:      it.Status,
+
+			PriceDecimal:      it.Price.String(),
+			TotalPriceDecimal: it.TotalPrice.String(),
+		})
+	}
+	return a
+}
+
+func TestAvroDecoder(t *testing.T) {
+	want := testOrder()
+	srv, hits := registryServer(t, map[int]string{7: orderv1.AvroSchema})
+
+	dec, err := NewAvroDecoder(NewRegistryClient(srv.URL+"/", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := avro.Marshal(dec.reader, toAvro(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := framed(7, payload)
+
+	for range 3 {
+		got, err := dec.Decode(context.Background(), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sameOrder(t, got, want)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("registry was asked %d times, want once", n)
+	}
+}
+
+func TestAvroDecoderAmounts(t *testing.T) {
+	srv, _ := registryServer(t, map[int]string{7: orderv1.AvroSchema})
+	dec, err := NewAvroDecoder(NewRegistryClient(srv.URL, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func(rec avroOrder) (*domain.Order, error) {
+		payload, err := avro.Marshal(dec.reader, rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dec.Decode(context.Background(), framed(7, payload))
+	}
+
+	for _, want := range fractionalOrders(t) {
+		got, err := decode(toAvro(want))
+		if err != nil {
+			t.Fatalf("%s: %v", want.Payment.Currency, err)
+		}
+		sameOrder(t, got, want)
+	}
+
+	// writers of the first schema send whole units only
+	want := testOrder()
+	old := toAvro(want)
+	old.Payment.AmountDecimal, old.Payment.DeliveryCostDecimal, old.Payment.GoodsTotalDecimal, old.Payment.CustomFeeDecimal = "", "", "", ""
+	old.Items[0].PriceDecimal, old.Items[0].TotalPriceDecimal = "", ""
+	got, err := decode(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameOrder(t, got, want)
+
+	bad := toAvro(fractionalOrders(t)[1])
+	bad.Items[0].PriceDecimal = "12.3456"
+	if _, err := decode(bad); err == nil {
+		t.Error("price finer than the currency was accepted")
+	}
+}
+
+func TestAvroDecoderEvolvedWriter(t *testing.T) {
+	// the writer dropped locale and added a field we do not know
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(orderv1.AvroSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	fields := make([]any, 0)
+	for _, f := range schema["fields"].([]any) {
+		if f.(map[string]any)["name"] != "locale" {
+			fields = append(fields, f)
+		}
+	}
+	schema["fields"] = append(fields, map[string]any{"name": "gift_wrap", "type": "boolean"})
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := avro.Parse(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := testOrder()
+	rec := toAvro(want)
+	var written map[string]any
+	b, err := avro.Marshal(avro.MustParse(orderv1.AvroSchema), rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = avro.Unmarshal(avro.MustParse(orderv1.AvroSchema), b, &written); err != nil {
+		t.Fatal(err)
+	}
+	delete(written, "locale")
+	written["gift_wrap"] = true
+	payload, err := avro.Marshal(writer, written)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, _ := registryServer(t, map[int]string{8: string(raw)})
+	dec, err := NewAvroDecoder(NewRegistryClient(srv.URL, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := dec.Decode(context.Background(), framed(8, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// locale takes the reader default
+	want.Locale = ""
+	sameOrder(t, got, want)
+}
+
+func TestAvroDecoderRejects(t *testing.T) {
+	srv, _ := registryServer(t, map[int]string{7: orderv1.AvroSchema, 9: `{"type": "string"}`})
+	dec, err := NewAvroDecoder(NewRegistryClient(srv.URL, "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for name, data := range map[string][]byte{
+		"not framed":        []byte(`{"order_uid":"x"}`),
+		"unknown schema":    framed(404, []byte{0}),
+		"incompatible":      framed(9, []byte{0}),
+		"truncated payload": framed(7, []byte{2}),
+	} {
+		if _, err := dec.Decode(ctx, data); err == nil {
+			t.Errorf("%s was accepted", name)
+		} else if name == "unknown schema" && !strings.Contains(err.Error(), "status 404") {
+			t.Errorf("unknown schema: error %v, want the registry status", err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"order-service/internal/domain"
+	"strings"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+var ErrUnknownFormat = errors.New("unknown message format")
+
+// Decoder turns a message payload into an order.
+type Decoder interface {
+	Decode(ctx context.Context, data []byte) (*domain.Order, error)
+}
+
+// contentTypes maps content-type header values to formats.
+var contentTypes = map[string]string{
+	"application/json":       FormatJSON,
+	"application/x-protobuf": FormatProtobuf,
+	"application/protobuf":   FormatProtobuf,
+	"application/avro":       FormatAvro,
+	"avro/binary":            FormatAvro,
+}
+
+// Decoders picks a decoder per message by its content type.
+type Decoders struct {
+	byFormat map[string]Decoder
+	fallback Decoder
+}
+
+// NewDecoders builds the set of supported decoders. defaultFormat is used for
+// messages without a content type, the Avro decoder is only available with
+// a schema registry. strictJSON turns on strict mode of the JSON decoder.
+func NewDecoders(defaultFormat string, registry *RegistryClient, strictJSON bool) (*Decoders, error) {
+	d := &Decoders{byFormat: map[string]Decoder{
+		FormatJSON:     NewJSONDecoder(DefaultUpcasters(), strictJSON),
+		FormatProtobuf: ProtobufDecoder{},
+	}}
+	if registry != nil {
+		avro, err := NewAvroDecoder(registry)
+		if err != nil {
+			return nil, err
+		}
+		d.byFormat[FormatAvro] = avro
+	}
+
+	if defaultFormat == "" {
+		defaultFormat = FormatJSON
+	}
+	fallback, ok := d.byFormat[defaultFormat]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, defaultFormat)
+	}
+	d.fallback = fallback
+	return d, nil
+}
+
+// For returns the decoder for a content-type header value, parameters such
+// as charset are ignored. An empty value selects the default format.
+func (d *Decoders) For(contentType string) (Decoder, error) {
+	if contentType == "" {
+		return d.fallback, nil
+	}
+
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	format, ok := contentTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+	if !ok {
+		return nil, fmt.Errorf("%w: content-type %q", ErrUnknownFormat, contentType)
+	}
+	dec, ok := d.byFormat[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s decoding is not configured", ErrUnknownFormat, format)
+	}
+	return dec, nil
+}
+
+// settle binds the amounts of a decoded order to its payment currency and
+// rejects orders whose totals do not add up.
+func settle(o *domain.Order, err error) (*domain.Order, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err = o.BindCurrency(); err != nil {
+		return nil, err
+	}
+	if err = o.CheckTotals(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// amount reads a decimal amount of currency, or whole units when the
+// decimal is empty, as the first protobuf and Avro schemas carried them.
+func amount(field, decimal string, units int64, currency string) (domain.Money, error) {
+	if decimal == "" {
+		return domain.MoneyFromMajor(units, currency), nil
+	}
+	m, err := domain.ParseMoney(decimal, currency)
+	if err != nil {
+		return domain.Money{}, fmt.Errorf("%s: %w", field, err)
+	}
+	return m, nil
+}
+
+// Confluent wire format: magic byte 0, big endian schema id, payload.
+const wireMagic = 0
+
+func splitWireFormat(data []byte) (schemaID int, payload []byte, err error) {
+	if len(data) < 5 || data[0] != wireMagic {
+		return 0, nil, errors.New("not in schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
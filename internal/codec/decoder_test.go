@@ -0,0 +1,157 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"order-service/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testOrder has whole amounts, which every format can carry, and totals
+// that add up.
+func testOrder() *domain.Order {
+	usd := func(units int64) domain.Money { return domain.MoneyFromMajor(units, "USD") }
+	return &domain.Order{
+		OrderId:         uuid.MustParse("b563feb7-b2b8-4b6b-8b6b-7a1c2d3e4f50"),
+		TrackNumber:     "WBILMTESTTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerId:      "test",
+		DeliveryService: "meest",
+		ShardKey:        9,
+		SmId:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Delivery: domain.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: domain.Payment{
+			TransactionId: "b563feb7b2b84b6test",
+			Currency:      "USD",
+			Provider:      "wbpay",
+			Amount:        usd(1817),
+			PaymentDt:     1637907727,
+			Bank:          "alpha",
+			DeliveryCost:  usd(1500),
+			GoodsTotal:    usd(317),
+			CustomFee:     usd(0),
+		},
+		Items: []domain.Item{{
+			ChrtId:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       usd(453),
+			RID:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  usd(317),
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      "202",
+		}},
+	}
+}
+
+// sameOrder compares orders by their JSON form.
+// fractionalOrders returns the test order with kopecks in RUB and with
+// fils, thousandths of a dinar, in KWD.
+func fractionalOrders(t *testing.T) []*domain.Order {
+	t.Helper()
+	in := func(currency string, amounts ...string) *domain.Order {
+		m := make([]domain.Money, len(amounts))
+		for i, s := range amounts {
+			var err error
+			if m[i], err = domain.ParseMoney(s, currency); err != nil {
+				t.Fatal(err)
+			}
+		}
+		o := testOrder()
+		o.Payment.Currency = currency
+		o.Payment.Amount, o.Payment.DeliveryCost, o.Payment.GoodsTotal, o.Payment.CustomFee = m[0], m[1], m[2], m[3]
+		o.Items[0].Price, o.Items[0].TotalPrice = m[4], m[2]
+		return o
+	}
+	return []*domain.Order{
+		// amount, delivery_cost, goods_total, custom_fee, price
+		in("RUB", "18.17", "15.00", "3.17", "0", "4.53"),
+		in("KWD", "10.266", "1.500", "8.641", "0.125", "12.345"),
+	}
+}
+
+func sameOrder(t *testing.T, got, want *domain.Order) {
+	t.Helper()
+	a, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("got order\n%s\nwant\n%s", a, b)
+	}
+}
+
+// framed prefixes payload with the schema registry wire format header.
+func framed(id int, payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint32([]byte{wireMagic}, uint32(id)), payload...)
+}
+
+func TestSplitWireFormat(t *testing.T) {
+	id, payload, err := splitWireFormat(framed(258, []byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 258 || string(payload) != "data" {
+		t.Errorf("got schema %d payload %q, want 258 %q", id, payload, "data")
+	}
+
+	for _, data := range [][]byte{nil, {wireMagic, 0, 0}, {1, 0, 0, 0, 1, 'x'}} {
+		if _, _, err := splitWireFormat(data); err == nil {
+			t.Errorf("splitWireFormat(%v) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestDecodersFor(t *testing.T) {
+	d, err := NewDecoders("", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for contentType, want := range map[string]Decoder{
+		"":                                JSONDecoder{},
+		"application/json; charset=utf-8": JSONDecoder{},
+		"Application/X-Protobuf":          ProtobufDecoder{},
+	} {
+		got, err := d.For(contentType)
+		if err != nil {
+			t.Errorf("For(%q): %v", contentType, err)
+			continue
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(want) {
+			t.Errorf("For(%q) = %T, want %T", contentType, got, want)
+		}
+	}
+
+	// Avro needs a schema registry
+	for _, contentType := range []string{"application/avro", "text/plain"} {
+		if _, err := d.For(contentType); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("For(%q) error %v, want ErrUnknownFormat", contentType, err)
+		}
+	}
+	if _, err := NewDecoders(FormatAvro, nil, false); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("default avro without a registry: error %v, want ErrUnknownFormat", err)
+	}
+}
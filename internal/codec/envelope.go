@@ -0,0 +1,83 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CurrentOrderVersion is the payload version decoded straight into domain.Order.
+const CurrentOrderVersion = 2
+
+var (
+	ErrUnknownSchemaVersion = errors.New("unknown schema version")
+	ErrUnknownEventType     = errors.New("unknown event type")
+)
+
+// Envelope wraps every order event on the wire. Messages without an
+// envelope are the bare orders sent before it existed, they count as version 1.
+type Envelope struct {
+	EventType      string          `json:"event_type"`
+	SchemaVersion  int             `json:"schema_version"`
+	Producer       string          `json:"producer"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// Upcaster migrates a payload from its version to the next one.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// Upcasters chains upcasters by the version they migrate from.
+type Upcasters map[int]Upcaster
+
+// DefaultUpcasters knows every historical payload version.
+func DefaultUpcasters() Upcasters {
+	return Upcasters{
+		// v1 is the bare order produced before envelopes, the order layout did not change
+		1: func(p json.RawMessage) (json.RawMessage, error) { return p, nil },
+	}
+}
+
+// Upcast migrates payload from version to CurrentOrderVersion.
+func (u Upcasters) Upcast(version int, payload json.RawMessage) (json.RawMessage, error) {
+	if version < 1 || version > CurrentOrderVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownSchemaVersion, version)
+	}
+	for v := version; v < CurrentOrderVersion; v++ {
+		up, ok := u[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no upcaster from %d", ErrUnknownSchemaVersion, v)
+		}
+		var err error
+		if payload, err = up(payload); err != nil {
+			return nil, fmt.Errorf("upcast %d->%d: %w", v, v+1, err)
+		}
+	}
+	return payload, nil
+}
+
+// openEnvelope returns the current-version order payload of a JSON message
+// and the producer named in the envelope.
+func openEnvelope(data []byte, upcasters Upcasters) (json.RawMessage, string, error) {
+	var probe struct {
+		SchemaVersion *int            `json:"schema_version"`
+		Payload       json.RawMessage `json:"payload"`
+		EventType     string          `json:"event_type"`
+		Producer      string          `json:"producer"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, "", err
+	}
+	if probe.SchemaVersion == nil || probe.Payload == nil {
+		payload, err := upcasters.Upcast(1, data)
+		return payload, "", err
+	}
+
+	if probe.EventType != "" && !orderEvents[probe.EventType] {
+		return nil, probe.Producer, fmt.Errorf("%w: %q", ErrUnknownEventType, probe.EventType)
+	}
+	payload, err := upcasters.Upcast(*probe.SchemaVersion, probe.Payload)
+	return payload, probe.Producer, err
+}
@@ -0,0 +1,32 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// currencyBinder is an event with amounts, bound to its currency once decoded.
+type currencyBinder interface {
+	BindCurrency() error
+}
+
+// DecodeEvent decodes a plain JSON event of the auxiliary topics (payments,
+// delivery statuses, cancellations). Unknown fields are ignored, missing
+// required fields are an error whatever the strict mode of the order decoder.
+func DecodeEvent[T any](data []byte) (T, error) {
+	var ev T
+	if report := inspectFields(data, reflect.TypeFor[T]()); len(report.missing) > 0 {
+		return ev, fmt.Errorf("%w: %s", ErrMissingFields, strings.Join(report.missing, ", "))
+	}
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return ev, err
+	}
+	if b, ok := any(&ev).(currencyBinder); ok {
+		if err := b.BindCurrency(); err != nil {
+			return ev, err
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// fieldReport lists JSON keys the target type does not know and keys the
+// type marks with validate:"required" that are absent or null, as dotted paths.
+type fieldReport struct {
+	unknown []string
+	missing []string
+}
+
+func inspectFields(data json.RawMessage, t reflect.Type) fieldReport {
+	var r fieldReport
+	r.walk(data, t, "")
+	return r
+}
+
+func (r *fieldReport) walk(data json.RawMessage, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// types decoding themselves (time.Time, uuid.UUID) are leaves
+	if reflect.PointerTo(t).Implements(jsonUnmarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return // type errors are reported by the decoder itself
+		}
+		known := make(map[string]struct{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			known[name] = struct{}{}
+
+			raw, ok := obj[name]
+			if !ok || bytes.Equal(raw, []byte("null")) {
+				if f.Tag.Get("validate") == "required" {
+					r.missing = append(r.missing, path+name)
+				}
+				continue
+			}
+			r.walk(raw, f.Type, path+name+".")
+		}
+		for name := range obj {
+			if _, ok := known[name]; !ok {
+				r.unknown = append(r.unknown, path+name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var list []json.RawMessage
+		if json.Unmarshal(data, &list) != nil {
+			return
+		}
+		for _, item := range list {
+			r.walk(item, t.Elem(), strings.TrimSuffix(path, ".")+"[].")
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order-service/internal/domain"
+	"order-service/internal/metrics"
+	"reflect"
+	"strings"
+)
+
+// orderEvents are the envelope event types carrying a full order.
+var orderEvents = map[string]bool{
+	domain.EventOrderCreated: true,
+	domain.EventOrderUpdated: true,
+}
+
+var (
+	ErrUnknownFields = errors.New("unknown fields")
+	ErrMissingFields = errors.New("missing required fields")
+)
+
+var orderType = reflect.TypeFor[domain.Order]()
+
+// JSONDecoder reads enveloped or bare JSON orders, older payload versions
+// are migrated by the upcasters first.
+//
+// In strict mode unknown fields and absent required fields (validate:"required"
+// on the domain structs) reject the message. Lenient mode accepts it but
+// logs and counts unknown fields per producer.
+type JSONDecoder struct {
+	upcasters Upcasters
+	strict    bool
+}
+
+func NewJSONDecoder(u Upcasters, strict bool) JSONDecoder {
+	return JSONDecoder{upcasters: u, strict: strict}
+}
+
+func (d JSONDecoder) Decode(_ context.Context, data []byte) (*domain.Order, error) {
+	payload, producer, err := openEnvelope(data, d.upcasters)
+	if err != nil {
+		return nil, err
+	}
+	if producer == "" {
+		producer = "unknown"
+	}
+
+	report := inspectFields(payload, orderType)
+	if d.strict {
+		if len(report.unknown) > 0 {
+			return nil, fmt.Errorf("%w from %s: %s", ErrUnknownFields, producer, strings.Join(report.unknown, ", "))
+		}
+		if len(report.missing) > 0 {
+			return nil, fmt.Errorf("%w from %s: %s", ErrMissingFields, producer, strings.Join(report.missing, ", "))
+		}
+	} else if len(report.unknown) > 0 {
+		log.Printf("unknown fields from %s ignored: %s", producer, strings.Join(report.unknown, ", "))
+		// field names come from the payload, they stay in the log, not in label values
+		metrics.ConsumerUnknownFields.WithLabelValues(producer).Add(float64(len(report.unknown)))
+	}
+
+	var ord domain.Order
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	if d.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&ord); err != nil {
+		return nil, err
+	}
+	return settle(&ord, nil)
+}
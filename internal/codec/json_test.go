@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func envelope(t *testing.T, eventType string, version int, payload []byte) []byte {
+	t.Helper()
+	data, err := json.Marshal(Envelope{
+		EventType:     eventType,
+		SchemaVersion: version,
+		Producer:      "test-producer",
+		Payload:       payload,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestJSONDecoder(t *testing.T) {
+	want := testOrder()
+	bare, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an unknown field, a lenient decoder ignores it
+	extra := []byte(strings.Replace(string(bare), `"entry"`, `"gift_wrap":true,"entry"`, 1))
+
+	ctx := context.Background()
+	lenient := NewJSONDecoder(DefaultUpcasters(), false)
+	strict := NewJSONDecoder(DefaultUpcasters(), true)
+
+	for name, data := range map[string][]byte{
+		"bare":          bare,
+		"envelope v1":   envelope(t, "order.created", 1, bare),
+		"envelope v2":   envelope(t, "order.updated", CurrentOrderVersion, bare),
+		"unknown field": extra,
+	} {
+		got, err := lenient.Decode(ctx, data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		sameOrder(t, got, want)
+	}
+
+	if _, err := strict.Decode(ctx, bare); err != nil {
+		t.Errorf("strict: %v", err)
+	}
+	if _, err := strict.Decode(ctx, extra); !errors.Is(err, ErrUnknownFields) {
+		t.Errorf("strict with an unknown field: error %v, want ErrUnknownFields", err)
+	}
+	missing := []byte(strings.Replace(string(bare), `"track_number":"WBILMTESTTRACK",`, "", 1))
+	if _, err := strict.Decode(ctx, missing); !errors.Is(err, ErrMissingFields) {
+		t.Errorf("strict without track_number: error %v, want ErrMissingFields", err)
+	}
+}
+
+func TestJSONDecoderRejects(t *testing.T) {
+	bare, err := json.Marshal(testOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dec := NewJSONDecoder(DefaultUpcasters(), false)
+
+	if _, err := dec.Decode(ctx, envelope(t, "order.deleted", 2, bare)); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("unknown event type: error %v, want ErrUnknownEventType", err)
+	}
+	if _, err := dec.Decode(ctx, envelope(t, "order.created", CurrentOrderVersion+1, bare)); !errors.Is(err, ErrUnknownSchemaVersion) {
+		t.Errorf("future version: error %v, want ErrUnknownSchemaVersion", err)
+	}
+
+	o := testOrder()
+	o.Payment.Amount = o.Payment.Amount.Mul(2)
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Decode(ctx, data); err == nil {
+		t.Error("amount not adding up was accepted")
+	}
+
+	if _, err := dec.Decode(ctx, []byte("{")); err == nil {
+		t.Error("broken JSON was accepted")
+	}
+}
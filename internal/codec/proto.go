@@ -0,0 +1,211 @@
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	orderv1 "order-service/api/order/v1"
+	"order-service/internal/domain"
+)
+
+// OrderToProto converts a domain order to its protobuf form. Amounts are
+// written as decimals and, for old readers, as whole units.
+func OrderToProto(o *domain.Order) *orderv1.Order {
+	items := make([]*orderv1.Item, 0, len(o.Items))
+	for _, it := range o.Items {
+		items = append(items, &orderv1.Item{
+			ChrtId:            it.ChrtId,
+			TrackNumber:       it.TrackNumber,
+			Price:             it.Price.Major(),
+			Rid:               it.RID,
+			Name:              it.Name,
+			Sale:              int64(it.Sale),
+			Size:              it.Size,
+			TotalPrice:        it.TotalPrice.Major(),
+			NmId:              it.NmID,
+			Brand:             it.Brand,
+			Status:            it.Status,
+			PriceDecimal:      it.Price.String(),
+			TotalPriceDecimal: it.TotalPrice.String(),
+		})
+	}
+
+	return &orderv1.Order{
+		OrderUid:          o.OrderId.String(),
+		TrackNumber:       o.TrackNumber,
+		Entry:             o.Entry,
+		Locale:            o.Locale,
+		InternalSignature: o.InternalSignature,
+		CustomerId:        o.CustomerId,
+		DeliveryService:   o.DeliveryService,
+		Shardkey:          o.ShardKey,
+		SmId:              int64(o.SmId),
+		DateCreated:       timestamppb.New(o.DateCreated),
+		Status:            o.Status,
+		Delivery: &orderv1.Delivery{
+			Name:    o.Delivery.Name,
+			Phone:   o.Delivery.Phone,
+			Zip:     o.Delivery.Zip,
+			City:    o.Delivery.City,
+			Address: o.Delivery.Address,
+			Region:  o.Delivery.Region,
+			Email:   o.Delivery.Email,
+		},
+		Payment: &orderv1.Payment{
+			TransactionId:       o.Payment.TransactionId,
+			RequestId:           o.Payment.RequestId,
+			Currency:            o.Payment.Currency,
+			Provider:            o.Payment.Provider,
+			Amount:              o.Payment.Amount.Major(),
+			PaymentDt:           o.Payment.PaymentDt,
+			Bank:                o.Payment.Bank,
+			DeliveryCost:        o.Payment.DeliveryCost.Major(),
+			GoodsTotal:          o.Payment.GoodsTotal.Major(),
+			CustomFee:           o.Payment.CustomFee.Major(),
+			AmountDecimal:       o.Payment.Amount.String(),
+			DeliveryCostDecimal: o.Payment.DeliveryCost.String(),
+			GoodsTotalDecimal:   o.Payment.GoodsTotal.String(),
+			CustomFeeDecimal:    o.Payment.CustomFee.String(),
+		},
+		Items: items,
+	}
+}
+
+// OrderFromProto converts a protobuf order, empty order_uid gets a fresh id.
+func OrderFromProto(p *orderv1.Order) (*domain.Order, error) {
+	id := uuid.New()
+	if p.GetOrderUid() != "" {
+		var err error
+		if id, err = uuid.Parse(p.GetOrderUid()); err != nil {
+			return nil, fmt.Errorf("invalid order_uid: %w", err)
+		}
+	}
+
+	cur := p.GetPayment().GetCurrency()
+	o := &domain.Order{
+		OrderId:           id,
+		TrackNumber:       p.GetTrackNumber(),
+		Entry:             p.GetEntry(),
+		Locale:            p.GetLocale(),
+		InternalSignature: p.GetInternalSignature(),
+		CustomerId:        p.GetCustomerId(),
+		DeliveryService:   p.GetDeliveryService(),
+		ShardKey:          p.GetShardkey(),
+		SmId:              int(p.GetSmId()),
+		DateCreated:       p.GetDateCreated().AsTime(),
+		Status:            p.GetStatus(),
+		Items:             make([]domain.Item, 0, len(p.GetItems())),
+	}
+	if p.DateCreated == nil {
+		o.DateCreated = timestamppb.Now().AsTime()
+	}
+
+	if d := p.GetDelivery(); d != nil {
+		o.Delivery = domain.Delivery{
+			Name:    d.GetName(),
+			Phone:   d.GetPhone(),
+			Zip:     d.GetZip(),
+			City:    d.GetCity(),
+			Address: d.GetAddress(),
+			Region:  d.GetRegion(),
+			Email:   d.GetEmail(),
+		}
+	}
+
+	var err error
+	if pay := p.GetPayment(); pay != nil {
+		o.Payment = domain.Payment{
+			TransactionId: pay.GetTransactionId(),
+			RequestId:     pay.GetRequestId(),
+			Currency:      pay.GetCurrency(),
+			Provider:      pay.GetProvider(),
+			PaymentDt:     pay.GetPaymentDt(),
+			Bank:          pay.GetBank(),
+		}
+		for _, f := range []struct {
+			name    string
+			to      *domain.Money
+			decimal string
+			units   int64
+		}{
+			{"payment.amount", &o.Payment.Amount, pay.GetAmountDecimal(), pay.GetAmount()},
+			{"payment.delivery_cost", &o.Payment.DeliveryCost, pay.GetDeliveryCostDecimal(), pay.GetDeliveryCost()},
+			{"payment.goods_total", &o.Payment.GoodsTotal, pay.GetGoodsTotalDecimal(), pay.GetGoodsTotal()},
+			{"payment.custom_fee", &o.Payment.CustomFee, pay.GetCustomFeeDecimal(), pay.GetCustomFee()},
+		} {
+			if *f.to, err = amount(f.name, f.decimal, f.units, cur); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, it := range p.GetItems() {
+		item := domain.Item{
+			ChrtId:      it.GetChrtId(),
+			TrackNumber: it.GetTrackNumber(),
+			RID:         it.GetRid(),
+			Name:        it.GetName(),
+			Sale:        int(it.GetSale()),
+			Size:        it.GetSize(),
+			NmID:        it.GetNmId(),
+			Brand:       it.GetBrand(),
+			Status:      it.GetStatus(),
+		}
+		if item.Price, err = amount(fmt.Sprintf("items[%d].price", i), it.GetPriceDecimal(), it.GetPrice(), cur); err != nil {
+			return nil, err
+		}
+		if item.TotalPrice, err = amount(fmt.Sprintf("items[%d].total_price", i), it.GetTotalPriceDecimal(), it.GetTotalPrice(), cur); err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, item)
+	}
+
+	return o, nil
+}
+
+// ProtobufDecoder reads order.v1.Order messages, either raw or framed in the
+// schema registry wire format. The framing is detected by the leading zero
+// byte, which a raw protobuf message can never start with.
+type ProtobufDecoder struct{}
+
+func (ProtobufDecoder) Decode(_ context.Context, data []byte) (*domain.Order, error) {
+	if len(data) > 0 && data[0] == wireMagic {
+		_, rest, err := splitWireFormat(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = skipMessageIndexes(rest); err != nil {
+			return nil, err
+		}
+	}
+
+	var p orderv1.Order
+	if err := proto.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if p.GetOrderUid() == "" {
+		return nil, errors.New("missing order_uid")
+	}
+	return settle(OrderFromProto(&p))
+}
+
+// skipMessageIndexes drops the zigzag varint list of message indexes that
+// follows the schema id in protobuf framing.
+func skipMessageIndexes(data []byte) ([]byte, error) {
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, errors.New("bad message index count")
+	}
+	data = data[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(data); n <= 0 {
+			return nil, errors.New("bad message index")
+		}
+		data = data[n:]
+	}
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"google.golang.org/protobuf/proto"
+	"testing"
+)
+
+func TestProtobufDecoder(t *testing.T) {
+	want := testOrder()
+	raw, err := proto.Marshal(OrderToProto(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// framings carry a list of message indexes, [0] is written as a single zero
+	short := framed(3, append([]byte{0}, raw...))
+	long := framed(3, append(binary.AppendVarint(binary.AppendVarint(nil, 1), 0), raw...))
+
+	ctx := context.Background()
+	for name, data := range map[string][]byte{"raw": raw, "framed": short, "framed with indexes": long} {
+		got, err := ProtobufDecoder{}.Decode(ctx, data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		sameOrder(t, got, want)
+	}
+}
+
+func TestProtobufDecoderAmounts(t *testing.T) {
+	ctx := context.Background()
+	for _, want := range fractionalOrders(t) {
+		raw, err := proto.Marshal(OrderToProto(want))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ProtobufDecoder{}.Decode(ctx, raw)
+		if err != nil {
+			t.Fatalf("%s: %v", want.Payment.Currency, err)
+		}
+		sameOrder(t, got, want)
+	}
+
+	// producers of the first schema send whole units only
+	want := testOrder()
+	p := OrderToProto(want)
+	p.Payment.AmountDecimal, p.Payment.DeliveryCostDecimal, p.Payment.GoodsTotalDecimal, p.Payment.CustomFeeDecimal = "", "", "", ""
+	p.Items[0].PriceDecimal, p.Items[0].TotalPriceDecimal = "", ""
+	raw, err := proto.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ProtobufDecoder{}.Decode(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameOrder(t, got, want)
+}
+
+func TestProtobufDecoderRejects(t *testing.T) {
+	p := OrderToProto(testOrder())
+	p.OrderUid = ""
+	noID, err := proto.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = OrderToProto(testOrder())
+	p.Payment.AmountDecimal = "18.17.1"
+	badAmount, err := proto.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"no order_uid":     noID,
+		"bad amount":       badAmount,
+		"truncated header": {wireMagic, 0, 0},
+		"bad index count":  framed(3, []byte{0x80}),
+		"missing indexes":  framed(3, binary.AppendVarint(nil, 2)),
+		"garbage":          {0xff, 0xff, 0xff},
+	} {
+		if _, err := (ProtobufDecoder{}).Decode(context.Background(), data); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RegistryClient reads schemas from a Confluent compatible schema registry.
+// Schemas are immutable per id, so every id is fetched once and cached forever.
+type RegistryClient struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+
+	mu      sync.RWMutex
+	schemas map[int]string
+}
+
+func NewRegistryClient(baseURL, user, password string) *RegistryClient {
+	return &RegistryClient{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		schemas:  make(map[int]string),
+	}
+}
+
+func (c *RegistryClient) SchemaByID(ctx context.Context, id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", c.baseURL, id), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return "", fmt.Errorf("schema registry: schema %d: status %d: %s", id, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out struct {
+		Schema string `json:"schema"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("schema registry: schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = out.Schema
+	c.mu.Unlock()
+	return out.Schema, nil
+}
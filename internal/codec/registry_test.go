@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRegistryClient(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if accept := r.Header.Get("Accept"); accept != "application/vnd.schemaregistry.v1+json" {
+			t.Errorf("got Accept %q", accept)
+		}
+		switch r.URL.Path {
+		case "/schemas/ids/1":
+			w.Write([]byte(`{"schema": "\"string\""}`))
+		case "/schemas/ids/2":
+			w.Write([]byte(`{"schema": `))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewRegistryClient(srv.URL, "user", "secret")
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema, err := c.SchemaByID(ctx, 1)
+			if err != nil {
+				t.Error(err)
+			} else if schema != `"string"` {
+				t.Errorf("got schema %s", schema)
+			}
+		}()
+	}
+	wg.Wait()
+	before := hits.Load()
+	if _, err := c.SchemaByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != before {
+		t.Error("a cached schema was fetched again")
+	}
+
+	if _, err := c.SchemaByID(ctx, 2); err == nil {
+		t.Error("broken response was accepted")
+	}
+	_, err := c.SchemaByID(ctx, 3)
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("unknown schema: error %v, want status 404", err)
+	}
+	// failures are not cached
+	n := hits.Load()
+	c.SchemaByID(ctx, 3)
+	if hits.Load() != n+1 {
+		t.Error("a failed lookup was cached")
+	}
+
+	anon := NewRegistryClient(srv.URL, "", "")
+	if _, err := anon.SchemaByID(ctx, 1); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Errorf("without credentials: error %v, want status 401", err)
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// Analytics periods.
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// AnalyticsDimensions are the order attributes sales can be grouped by.
+var AnalyticsDimensions = []string{"delivery_service", "provider", "bank", "locale", "currency"}
+
+// Rankings of TopSales.
+const (
+	TopByBrand = "brand"
+	TopByNmID  = "nm_id"
+)
+
+// AnalyticsQuery selects days From to To inclusive. Amounts are valued in
+// Currency at the rate of each day, orders of a day without a rate are only
+// counted as unpriced.
+type AnalyticsQuery struct {
+	From     time.Time
+	To       time.Time
+	Currency string
+}
+
+// SalesRow aggregates the orders of a period sharing a dimension value.
+type SalesRow struct {
+	Period        time.Time `json:"period"`
+	Key           string    `json:"key"`
+	Orders        int64     `json:"orders"`
+	Revenue       Money     `json:"revenue"`
+	GoodsTotal    Money     `json:"goods_total"`
+	DeliveryCost  Money     `json:"delivery_cost"`
+	AverageBasket Money     `json:"average_basket"`
+	Items         int64     `json:"items"`
+	ItemsPerOrder float64   `json:"items_per_order"`
+	SaleShare     float64   `json:"sale_share"`
+	Unpriced      int64     `json:"unpriced_orders"`
+}
+
+// TopRow is a brand or an nm_id ranked by revenue.
+type TopRow struct {
+	Key       string  `json:"key"`
+	Revenue   Money   `json:"revenue"`
+	Units     int64   `json:"units"`
+	SaleShare float64 `json:"sale_share"`
+	Unpriced  int64   `json:"unpriced_units"`
+}
+
+// SalesSummary sums up all orders of a query.
+type SalesSummary struct {
+	Orders        int64   `json:"orders"`
+	Revenue       Money   `json:"revenue"`
+	AverageBasket Money   `json:"average_basket"`
+	Items         int64   `json:"items"`
+	ItemsPerOrder float64 `json:"items_per_order"`
+	SaleShare     float64 `json:"sale_share"`
+	Unpriced      int64   `json:"unpriced_orders"`
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ErasedValue replaces delivery PII once a customer asked for erasure.
+const ErasedValue = "[erased]"
+
+// CustomerExport is the archive returned to a customer asking for their data.
+type CustomerExport struct {
+	CustomerId string    `json:"customer_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Orders     []*Order  `json:"orders"`
+}
+
+// Erase anonymizes personal fields of the delivery in place.
+// City and region are kept, they are not enough to identify a person.
+func (d *Delivery) Erase() {
+	d.Name = ErasedValue
+	d.Phone = ErasedValue
+	d.Zip = ""
+	d.Address = ErasedValue
+	d.Email = ErasedValue
+}
+
+// Masked returns the delivery with personal fields masked for exports,
+// enough is kept to tell records apart: the initial of the name, the last
+// digits of the phone and the domain of the email.
+func (d Delivery) Masked() Delivery {
+	m := d
+	m.Name = keep(d.Name, 1, 0)
+	m.Phone = keep(d.Phone, 0, 4)
+	m.Zip = ""
+	m.Address = "***"
+	if user, host, ok := strings.Cut(d.Email, "@"); ok {
+		m.Email = keep(user, 1, 0) + "@" + host
+	} else {
+		m.Email = keep(d.Email, 1, 0)
+	}
+	return m
+}
+
+// keep masks s except its first head and last tail runes.
+func keep(s string, head, tail int) string {
+	r := []rune(s)
+	if len(r) <= head+tail {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:head]) + strings.Repeat("*", len(r)-head-tail) + string(r[len(r)-tail:])
+}
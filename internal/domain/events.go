@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	StatusCreated   = "created"
+	StatusCancelled = "cancelled"
+)
+
+// PaymentConfirmation arrives from the payments topic once the provider settled the payment.
+type PaymentConfirmation struct {
+	OrderUID      uuid.UUID `json:"order_uid" validate:"required"`
+	TransactionId string    `json:"transaction_id" validate:"required"`
+	RequestId     string    `json:"request_id"`
+	Currency      string    `json:"currency" validate:"required"`
+	Provider      string    `json:"provider" validate:"required"`
+	Amount        Money     `json:"amount" validate:"required"`
+	PaymentDt     int64     `json:"payment_dt" validate:"required"`
+	Bank          string    `json:"bank"`
+}
+
+// StatusUpdate changes the order status, from delivery tracking or a cancellation.
+// Updates older than the stored one are ignored, topics do not keep order across keys.
+type StatusUpdate struct {
+	OrderUID  uuid.UUID `json:"order_uid" validate:"required"`
+	Status    string    `json:"status" validate:"required"`
+	Reason    string    `json:"reason"`
+	UpdatedAt time.Time `json:"updated_at" validate:"required"`
+}
+
+// Cancellation arrives from the cancellations topic.
+type Cancellation struct {
+	OrderUID    uuid.UUID `json:"order_uid" validate:"required"`
+	Reason      string    `json:"reason"`
+	CancelledAt time.Time `json:"cancelled_at" validate:"required"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// ExportFilter selects orders of an export, empty fields match everything.
+// From is inclusive, To exclusive.
+type ExportFilter struct {
+	CustomerID      string
+	DeliveryService string
+	Currency        string
+	From            time.Time
+	To              time.Time
+}
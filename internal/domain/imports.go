@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ErrImportLost is returned for progress of a run whose job was taken over
+// by another worker after the lease ran out.
+var ErrImportLost = errors.New("import job was taken over")
+
+// ImportJob is a bulk load of orders from a file. RowsDone is the position
+// in the file up to which orders are stored, an interrupted job continues
+// from there. OrdersSkipped counts orders that were stored before, by an
+// earlier run, another import or Kafka.
+type ImportJob struct {
+	ID            int64      `json:"job_id"`
+	Source        string     `json:"source"`
+	Format        string     `json:"format"`
+	DryRun        bool       `json:"dry_run"`
+	Status        string     `json:"status"`
+	RowsDone      int64      `json:"rows_done"`
+	OrdersOK      int64      `json:"orders_ok"`
+	OrdersSkipped int64      `json:"orders_skipped"`
+	OrdersBad     int64      `json:"orders_bad"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	// Claim identifies the run holding a running job, only it saves progress.
+	Claim uuid.UUID `json:"-"`
+}
+
+// ImportRecord is one order read from an import file, Row and End are the
+// first and the last rows of the file it spans. Data is the order as JSON.
+type ImportRecord struct {
+	Row  int64
+	End  int64
+	Data []byte
+	// Err is set when the rows could not be read into an order.
+	Err error
+}
+
+// ImportRowError reports why the order starting at Row was rejected.
+type ImportRowError struct {
+	Row      int64  `json:"row"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Error    string `json:"error"`
+}
@@ -62,56 +62,57 @@ import (
 //}
 
 type Order struct {
-	OrderId           uuid.UUID `json:"order_uid"`
-	TrackNumber       string    `json:"track_number"`
-	Entry             string    `json:"entry"`
+	OrderId           uuid.UUID `json:"order_uid" validate:"required"`
+	TrackNumber       string    `json:"track_number" validate:"required"`
+	Entry             string    `json:"entry" validate:"required"`
 	Locale            string    `json:"locale"`
 	InternalSignature string    `json:"internal_signature"`
-	CustomerId        string    `json:"customer_id"`
-	DeliveryService   string    `json:"delivery_service"`
+	CustomerId        string    `json:"customer_id" validate:"required"`
+	DeliveryService   string    `json:"delivery_service" validate:"required"`
 	ShardKey          int64     `json:"shardkey"`
 	SmId              int       `json:"sm_id"`
-	DateCreated       time.Time `json:"date_created"`
+	DateCreated       time.Time `json:"date_created" validate:"required"`
+	Status            string    `json:"status,omitempty"`
 
-	Delivery Delivery `json:"delivery"`
-	Payment  Payment  `json:"payment"`
-	Items    []Item   `json:"items"`
+	Delivery Delivery `json:"delivery" validate:"required"`
+	Payment  Payment  `json:"payment" validate:"required"`
+	Items    []Item   `json:"items" validate:"required"`
 }
 
 type Delivery struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
+	Name    string `json:"name" validate:"required"`
+	Phone   string `json:"phone" validate:"required"`
 	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
+	City    string `json:"city" validate:"required"`
+	Address string `json:"address" validate:"required"`
 	Region  string `json:"region"`
 	Email   string `json:"email"`
 }
 
 type Payment struct {
-	TransactionId string `json:"transaction_id"`
+	TransactionId string `json:"transaction_id" validate:"required"`
 	RequestId     string `json:"request_id"`
-	Currency      string `json:"currency"`
-	Provider      string `json:"provider"`
-	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency" validate:"required"`
+	Provider      string `json:"provider" validate:"required"`
+	Amount        Money  `json:"amount" validate:"required"`
 	PaymentDt     int64  `json:"payment_dt"`
 	Bank          string `json:"bank"`
-	DeliveryCost  int64  `json:"delivery_cost"`
-	GoodsTotal    int64  `json:"goods_total"`
-	CustomFee     int64  `json:"custom_fee"`
+	DeliveryCost  Money  `json:"delivery_cost"`
+	GoodsTotal    Money  `json:"goods_total"`
+	CustomFee     Money  `json:"custom_fee"`
 }
 
 type Item struct {
 	ID          int64  `json:"-"`
-	ChrtId      int64  `json:"chrt_id"`
+	ChrtId      int64  `json:"chrt_id" validate:"required"`
 	TrackNumber string `json:"track_number"`
-	Price       int64  `json:"price"`
+	Price       Money  `json:"price" validate:"required"`
 	RID         string `json:"rid"`
 	Name        string `json:"name"`
 	Sale        int    `json:"sale"`
 	Size        string `json:"size"`
-	TotalPrice  int64  `json:"total_price"`
-	NmID        int64  `json:"nm_id"`
+	TotalPrice  Money  `json:"total_price" validate:"required"`
+	NmID        int64  `json:"nm_id" validate:"required"`
 	Brand       string `json:"brand"`
-	Status      string `json:"status"`
+	Status      string `json:"status" validate:"required"`
 }
@@ -0,0 +1,280 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgtype"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrMoneyPrecision   = errors.New("amount has more decimals than its currency")
+	ErrMoneyRange       = errors.New("amount out of range")
+	ErrTotalsMismatch   = errors.New("order totals do not add up")
+)
+
+// exponents lists ISO 4217 currencies whose minor unit is not a hundredth.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// unboundExponent is the scale of amounts read before their currency is
+// known, fine enough to bind any currency exactly.
+const unboundExponent = 3
+
+// Exponent returns the number of decimals of the minor unit of a currency,
+// 2 for codes not listed as exceptions.
+func Exponent(currency string) int {
+	if currency == "" {
+		return unboundExponent
+	}
+	if e, ok := exponents[strings.ToUpper(currency)]; ok {
+		return e
+	}
+	return 2
+}
+
+// Money is an amount in minor units of its currency: kopecks for RUB, yen
+// for JPY. On the wire and in Postgres NUMERIC it is a decimal in major
+// units, so 1817 is 1817 roubles, as before.
+//
+// Order amounts are decoded before the payment currency is known and are
+// unbound until Order.BindCurrency, see unboundExponent.
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// NewMoney returns minor units of currency.
+func NewMoney(minor int64, currency string) Money {
+	return Money{amount: minor, currency: strings.ToUpper(currency)}
+}
+
+// MoneyFromMajor returns whole units of currency.
+func MoneyFromMajor(units int64, currency string) Money {
+	m := NewMoney(0, currency)
+	m.amount = units * pow10(Exponent(currency))
+	return m
+}
+
+// ParseMoney reads a decimal in major units, "18.17" RUB is 1817 kopecks.
+func ParseMoney(s, currency string) (Money, error) {
+	m := NewMoney(0, currency)
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("bad amount %q", s)
+	}
+	return m, m.setRat(r)
+}
+
+func (m Money) Minor() int64      { return m.amount }
+func (m Money) Currency() string  { return m.currency }
+func (m Money) IsZero() bool      { return m.amount == 0 }
+func (m Money) Exponent() int     { return Exponent(m.currency) }
+func (m Money) Neg() Money        { m.amount = -m.amount; return m }
+func (m Money) unit() int64       { return pow10(m.Exponent()) }
+func (m Money) Mul(n int64) Money { m.amount *= n; return m }
+
+// Major returns whole major units, truncated toward zero. Protobuf and Avro
+// orders still carry them next to the decimal for old readers.
+func (m Money) Major() int64 {
+	return m.amount / m.unit()
+}
+
+// In binds an unbound amount to currency. Bound amounts are returned as is
+// for the same currency, converting between currencies is an error.
+func (m Money) In(currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	switch {
+	case m.currency == currency:
+		return m, nil
+	case m.currency != "":
+		return Money{}, fmt.Errorf("%w: %s amount as %s", ErrCurrencyMismatch, m.currency, currency)
+	}
+
+	to := NewMoney(0, currency)
+	return to, to.setRat(m.rat())
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.currency != o.currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	sum := m.amount + o.amount
+	if (sum > m.amount) != (o.amount > 0) {
+		return Money{}, ErrMoneyRange
+	}
+	m.amount = sum
+	return m, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+// Sum adds up amounts of one currency, an empty list is zero of currency.
+func Sum(currency string, list ...Money) (Money, error) {
+	total := NewMoney(0, currency)
+	for _, m := range list {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Discount takes percent off, rounding toward zero.
+func (m Money) Discount(percent int) Money {
+	m.amount = m.amount * int64(100-percent) / 100
+	return m
+}
+
+// Div splits the amount in n parts, rounding half away from zero. Zero
+// parts give zero.
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		m.amount = 0
+		return m
+	}
+	q, r := m.amount/n, m.amount%n
+	if 2*abs64(r) >= abs64(n) {
+		if (m.amount < 0) != (n < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	m.amount = q
+	return m
+}
+
+// Convert values the amount in currency at rate, units of currency per unit
+// of the amount currency, rounding half away from zero.
+func (m Money) Convert(rate *big.Rat, currency string) (Money, error) {
+	to := NewMoney(0, currency)
+	x := new(big.Rat).Mul(m.rat(), rate)
+	x.Mul(x, new(big.Rat).SetInt64(to.unit()))
+
+	q, r := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(x.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+	if !q.IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s %s in %s", ErrMoneyRange, m, m.currency, currency)
+	}
+	to.amount = q.Int64()
+	return to, nil
+}
+
+// Cmp compares amounts of one currency, -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.currency != o.currency {
+		return 0, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// String formats major units without trailing zeros, "1817" or "18.17".
+func (m Money) String() string {
+	return formatRat(m.rat(), m.Exponent())
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a number or a numeric string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	r, ok := new(big.Rat).SetString(strings.Trim(s, `"`))
+	if !ok {
+		return fmt.Errorf("bad amount %s", s)
+	}
+	return m.setRat(r)
+}
+
+// ScanNumeric implements pgtype.NumericScanner, NULL is zero.
+func (m *Money) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		m.amount = 0
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: %v", ErrMoneyRange, n)
+	}
+
+	r := new(big.Rat).SetInt(n.Int)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(n.Exp))), nil))
+	if n.Exp < 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+	return m.setRat(r)
+}
+
+// NumericValue implements pgtype.NumericValuer. Columns are NUMERIC(13,3),
+// enough for the thousandths of KWD or BHD.
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(m.amount), Exp: -int32(m.Exponent()), Valid: true}, nil
+}
+
+func (m Money) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.amount), big.NewInt(m.unit()))
+}
+
+func (m *Money) setRat(r *big.Rat) error {
+	minor := new(big.Rat).Mul(r, new(big.Rat).SetInt64(m.unit()))
+	if !minor.IsInt() {
+		return fmt.Errorf("%w: %s %s", ErrMoneyPrecision, formatRat(r, 9), m.currency)
+	}
+	if !minor.Num().IsInt64() {
+		return fmt.Errorf("%w: %s", ErrMoneyRange, r.FloatString(0))
+	}
+	m.amount = minor.Num().Int64()
+	return nil
+}
+
+func formatRat(r *big.Rat, exp int) string {
+	s := r.FloatString(exp)
+	if exp > 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func mustMoney(t *testing.T, s, currency string) Money {
+	t.Helper()
+	m, err := ParseMoney(s, currency)
+	if err != nil {
+		t.Fatalf("ParseMoney(%q, %q): %v", s, currency, err)
+	}
+	return m
+}
+
+func TestExponent(t *testing.T) {
+	for currency, want := range map[string]int{
+		"":    unboundExponent,
+		"USD": 2,
+		"rub": 2,
+		"XYZ": 2,
+		"JPY": 0,
+		"krw": 0,
+		"KWD": 3,
+		"BHD": 3,
+	} {
+		if got := Exponent(currency); got != want {
+			t.Errorf("Exponent(%q) = %d, want %d", currency, got, want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		s, currency string
+		minor       int64
+		str         string
+		err         error
+	}{
+		{s: "18.17", currency: "RUB", minor: 1817, str: "18.17"},
+		{s: "1817", currency: "RUB", minor: 181700, str: "1817"},
+		{s: "-18.17", currency: "RUB", minor: -1817, str: "-18.17"},
+		{s: "18.10", currency: "usd", minor: 1810, str: "18.1"},
+		{s: "1e3", currency: "USD", minor: 100000, str: "1000"},
+		{s: "0", currency: "USD", minor: 0, str: "0"},
+		{s: "1817", currency: "JPY", minor: 1817, str: "1817"},
+		{s: "-5", currency: "JPY", minor: -5, str: "-5"},
+		{s: "12.345", currency: "KWD", minor: 12345, str: "12.345"},
+		{s: "-0.005", currency: "KWD", minor: -5, str: "-0.005"},
+		{s: "1.234", currency: "", minor: 1234, str: "1.234"},
+		{s: "18.171", currency: "RUB", err: ErrMoneyPrecision},
+		{s: "18.5", currency: "JPY", err: ErrMoneyPrecision},
+		{s: "12.3456", currency: "KWD", err: ErrMoneyPrecision},
+		{s: "1.2345", currency: "", err: ErrMoneyPrecision},
+		{s: "100000000000000000", currency: "RUB", err: ErrMoneyRange},
+	} {
+		m, err := ParseMoney(tc.s, tc.currency)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("ParseMoney(%q, %q): got error %v, want %v", tc.s, tc.currency, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q): %v", tc.s, tc.currency, err)
+			continue
+		}
+		if m.Minor() != tc.minor || m.String() != tc.str {
+			t.Errorf("ParseMoney(%q, %q) = %d minor, %q, want %d, %q", tc.s, tc.currency, m.Minor(), m, tc.minor, tc.str)
+		}
+	}
+
+	for _, s := range []string{"", "abc", "18,17", "1/0"} {
+		if _, err := ParseMoney(s, "USD"); err == nil {
+			t.Errorf("ParseMoney(%q) was accepted", s)
+		}
+	}
+}
+
+func TestMoneyIn(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		from     Money
+		currency string
+		minor    int64
+		err      error
+	}{
+		{name: "unbound to RUB", from: mustMoney(t, "18.17", ""), currency: "RUB", minor: 1817},
+		{name: "unbound to lower case", from: mustMoney(t, "18.17", ""), currency: "rub", minor: 1817},
+		{name: "unbound negative to RUB", from: mustMoney(t, "-18.17", ""), currency: "RUB", minor: -1817},
+		{name: "unbound to JPY", from: mustMoney(t, "1817", ""), currency: "JPY", minor: 1817},
+		{name: "unbound to KWD", from: mustMoney(t, "1.234", ""), currency: "KWD", minor: 1234},
+		{name: "unbound fils to RUB", from: mustMoney(t, "1.234", ""), currency: "RUB", err: ErrMoneyPrecision},
+		{name: "unbound cents to JPY", from: mustMoney(t, "18.17", ""), currency: "JPY", err: ErrMoneyPrecision},
+		{name: "bound to the same", from: mustMoney(t, "18.17", "RUB"), currency: "RUB", minor: 1817},
+		{name: "bound to another", from: mustMoney(t, "18.17", "RUB"), currency: "USD", err: ErrCurrencyMismatch},
+		{name: "bound to unbound", from: mustMoney(t, "18.17", "RUB"), currency: "", err: ErrCurrencyMismatch},
+	} {
+		got, err := tc.from.In(tc.currency)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("%s: got error %v, want %v", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != NewMoney(tc.minor, tc.currency) {
+			t.Errorf("%s: got %d %s, want %d", tc.name, got.Minor(), got.Currency(), tc.minor)
+		}
+	}
+}
+
+func TestMoneyDiscount(t *testing.T) {
+	for _, tc := range []struct {
+		price, currency string
+		percent         int
+		want            string
+	}{
+		{price: "4.53", currency: "RUB", percent: 30, want: "3.17"},
+		{price: "-4.53", currency: "RUB", percent: 30, want: "-3.17"},
+		{price: "4.53", currency: "RUB", percent: 0, want: "4.53"},
+		{price: "4.53", currency: "RUB", percent: 100, want: "0"},
+		{price: "999", currency: "JPY", percent: 10, want: "899"},
+		{price: "-999", currency: "JPY", percent: 10, want: "-899"},
+		{price: "12.345", currency: "KWD", percent: 30, want: "8.641"},
+	} {
+		got := mustMoney(t, tc.price, tc.currency).Discount(tc.percent)
+		if got != mustMoney(t, tc.want, tc.currency) {
+			t.Errorf("%s %s less %d%% = %s, want %s", tc.price, tc.currency, tc.percent, got, tc.want)
+		}
+	}
+}
+
+func TestMoneyConvert(t *testing.T) {
+	for _, tc := range []struct {
+		amount, from string
+		rate         string
+		to, want     string
+		err          error
+	}{
+		{amount: "10", from: "USD", rate: "90.5", to: "RUB", want: "905"},
+		{amount: "100", from: "RUB", rate: "1/90", to: "USD", want: "1.11"},
+		{amount: "-100", from: "RUB", rate: "1/90", to: "USD", want: "-1.11"},
+		{amount: "1000", from: "JPY", rate: "0.0065", to: "USD", want: "6.5"},
+		{amount: "1", from: "RUB", rate: "1.5", to: "JPY", want: "2"},
+		{amount: "-1", from: "RUB", rate: "1.5", to: "JPY", want: "-2"},
+		{amount: "1", from: "RUB", rate: "1.4", to: "JPY", want: "1"},
+		{amount: "1.000", from: "KWD", rate: "3.25", to: "USD", want: "3.25"},
+		{amount: "3.25", from: "USD", rate: "0.3077", to: "KWD", want: "1"},
+		{amount: "0.01", from: "USD", rate: "0.5", to: "EUR", want: "0.01"},
+		{amount: "-0.01", from: "USD", rate: "0.5", to: "EUR", want: "-0.01"},
+		{amount: "90000000000", from: "USD", rate: "1000000000", to: "RUB", err: ErrMoneyRange},
+	} {
+		rate, ok := new(big.Rat).SetString(tc.rate)
+		if !ok {
+			t.Fatalf("bad rate %s", tc.rate)
+		}
+		got, err := mustMoney(t, tc.amount, tc.from).Convert(rate, tc.to)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("%s %s in %s: got error %v, want %v", tc.amount, tc.from, tc.to, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s in %s: %v", tc.amount, tc.from, tc.to, err)
+			continue
+		}
+		if got != mustMoney(t, tc.want, tc.to) {
+			t.Errorf("%s %s at %s = %s %s, want %s", tc.amount, tc.from, tc.rate, got, got.Currency(), tc.want)
+		}
+	}
+}
+
+func TestMoneyMismatchedCurrencies(t *testing.T) {
+	rub, usd := mustMoney(t, "1", "RUB"), mustMoney(t, "1", "USD")
+	if _, err := rub.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := rub.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := rub.Cmp(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := Sum("RUB", rub, usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+	if _, err := NewMoney(1<<62, "RUB").Add(NewMoney(1<<62, "RUB")); !errors.Is(err, ErrMoneyRange) {
+		t.Errorf("Add overflow: got error %v, want %v", err, ErrMoneyRange)
+	}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Outbox event types, written in the same transaction as the order itself.
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderUpdated       = "order.updated"
+	EventOrderStatusChanged = "order.status_changed"
+	EventPaymentConfirmed   = "order.payment_confirmed"
+)
+
+// EventTypes lists the outbox event types webhooks can subscribe to.
+var EventTypes = []string{EventOrderCreated, EventOrderUpdated, EventOrderStatusChanged, EventPaymentConfirmed}
+
+type OutboxEvent struct {
+	ID        int64
+	OrderUID  uuid.UUID
+	EventType string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
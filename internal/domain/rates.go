@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"math/big"
+	"time"
+)
+
+// ExchangeRate values one unit of Currency in the base currency on Date.
+// The base currency is configured, not stored, rates are reloaded when it
+// changes.
+type ExchangeRate struct {
+	Currency string
+	Date     time.Time
+	Rate     *big.Rat
+}
+
+// Valuation is a payment valued in another currency at the rates of the
+// order date.
+type Valuation struct {
+	Currency     string    `json:"currency"`
+	RateDate     time.Time `json:"rate_date"`
+	Amount       Money     `json:"amount"`
+	DeliveryCost Money     `json:"delivery_cost"`
+	GoodsTotal   Money     `json:"goods_total"`
+	CustomFee    Money     `json:"custom_fee"`
+}
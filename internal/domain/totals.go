@@ -0,0 +1,67 @@
+package domain
+
+import "fmt"
+
+// BindCurrency binds the amounts of the order, decoded without a currency,
+// to the payment currency. An order without a currency stays unbound.
+func (o *Order) BindCurrency() error {
+	cur := o.Payment.Currency
+	if cur == "" {
+		return nil
+	}
+
+	fields := []*Money{&o.Payment.Amount, &o.Payment.DeliveryCost, &o.Payment.GoodsTotal, &o.Payment.CustomFee}
+	for i := range o.Items {
+		fields = append(fields, &o.Items[i].Price, &o.Items[i].TotalPrice)
+	}
+	for _, f := range fields {
+		bound, err := f.In(cur)
+		if err != nil {
+			return err
+		}
+		*f = bound
+	}
+	return nil
+}
+
+// BindCurrency binds the amount to the payment currency.
+func (c *PaymentConfirmation) BindCurrency() (err error) {
+	c.Amount, err = c.Amount.In(c.Currency)
+	return err
+}
+
+// CheckTotals verifies that item totals follow price and sale, the goods
+// total is the sum of item totals and the amount adds up goods, delivery
+// and custom fee. Producers round item totals to whole units, so an item
+// may be off by less than one major unit.
+func (o *Order) CheckTotals() error {
+	p := o.Payment
+	goods := NewMoney(0, p.Currency)
+	for i, it := range o.Items {
+		if it.Sale < 0 || it.Sale > 100 {
+			return fmt.Errorf("%w: items[%d] sale %d%%", ErrTotalsMismatch, i, it.Sale)
+		}
+		diff, err := it.Price.Discount(it.Sale).Sub(it.TotalPrice)
+		if err != nil {
+			return err
+		}
+		if diff.Minor() <= -diff.unit() || diff.Minor() >= diff.unit() {
+			return fmt.Errorf("%w: items[%d] total_price %s, price %s with %d%% sale", ErrTotalsMismatch, i, it.TotalPrice, it.Price, it.Sale)
+		}
+		if goods, err = goods.Add(it.TotalPrice); err != nil {
+			return err
+		}
+	}
+	if goods != p.GoodsTotal {
+		return fmt.Errorf("%w: goods_total %s, items add up to %s", ErrTotalsMismatch, p.GoodsTotal, goods)
+	}
+
+	amount, err := Sum(p.Currency, p.GoodsTotal, p.DeliveryCost, p.CustomFee)
+	if err != nil {
+		return err
+	}
+	if amount != p.Amount {
+		return fmt.Errorf("%w: amount %s, goods_total + delivery_cost + custom_fee is %s", ErrTotalsMismatch, p.Amount, amount)
+	}
+	return nil
+}
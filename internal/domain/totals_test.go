@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+type totalsItem struct {
+	price    string
+	sale     int
+	total    string
+	currency string // the payment currency if empty
+}
+
+func TestCheckTotals(t *testing.T) {
+	for _, tc := range []struct {
+		name                         string
+		currency                     string
+		amount, delivery, goods, fee string
+		items                        []totalsItem
+		err                          error
+	}{
+		{
+			name: "kopecks", currency: "RUB",
+			amount: "18.67", delivery: "5", goods: "13.17", fee: "0.5",
+			items: []totalsItem{{price: "4.53", sale: 30, total: "3.17"}, {price: "10", total: "10"}},
+		},
+		{
+			name: "item total rounded to whole units", currency: "RUB",
+			amount: "18.5", delivery: "5", goods: "13", fee: "0.5",
+			items: []totalsItem{{price: "4.53", sale: 30, total: "3"}, {price: "10", total: "10"}},
+		},
+		{
+			name: "refund", currency: "RUB",
+			amount: "-18.67", delivery: "-5", goods: "-13.17", fee: "-0.5",
+			items: []totalsItem{{price: "-4.53", sale: 30, total: "-3.17"}, {price: "-10", total: "-10"}},
+		},
+		{
+			name: "yen", currency: "JPY",
+			amount: "1399", delivery: "500", goods: "899", fee: "0",
+			items: []totalsItem{{price: "999", sale: 10, total: "899"}},
+		},
+		{
+			name: "fils", currency: "KWD",
+			amount: "10.266", delivery: "1.5", goods: "8.641", fee: "0.125",
+			items: []totalsItem{{price: "12.345", sale: 30, total: "8.641"}},
+		},
+		{
+			name: "no items", currency: "USD",
+			amount: "5", delivery: "5", goods: "0", fee: "0",
+		},
+		{
+			name: "item total off by a unit", currency: "RUB",
+			amount: "17.67", delivery: "5", goods: "12.17", fee: "0.5",
+			items: []totalsItem{{price: "4.53", sale: 30, total: "2.17"}, {price: "10", total: "10"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "item total off by a yen", currency: "JPY",
+			amount: "1398", delivery: "500", goods: "898", fee: "0",
+			items: []totalsItem{{price: "999", sale: 10, total: "898"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "sale above 100", currency: "USD",
+			amount: "0", delivery: "0", goods: "0", fee: "0",
+			items: []totalsItem{{price: "10", sale: 101, total: "0"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "negative sale", currency: "USD",
+			amount: "11", delivery: "0", goods: "11", fee: "0",
+			items: []totalsItem{{price: "10", sale: -10, total: "11"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "goods total off by a fils", currency: "KWD",
+			amount: "10.267", delivery: "1.5", goods: "8.642", fee: "0.125",
+			items: []totalsItem{{price: "12.345", sale: 30, total: "8.641"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "amount without custom fee", currency: "RUB",
+			amount: "18.17", delivery: "5", goods: "13.17", fee: "0.5",
+			items: []totalsItem{{price: "4.53", sale: 30, total: "3.17"}, {price: "10", total: "10"}},
+			err:   ErrTotalsMismatch,
+		},
+		{
+			name: "item in another currency", currency: "RUB",
+			amount: "10", delivery: "0", goods: "10", fee: "0",
+			items: []totalsItem{{price: "10", total: "10", currency: "USD"}},
+			err:   ErrCurrencyMismatch,
+		},
+	} {
+		o := &Order{Payment: Payment{
+			Currency:     tc.currency,
+			Amount:       mustMoney(t, tc.amount, tc.currency),
+			DeliveryCost: mustMoney(t, tc.delivery, tc.currency),
+			GoodsTotal:   mustMoney(t, tc.goods, tc.currency),
+			CustomFee:    mustMoney(t, tc.fee, tc.currency),
+		}}
+		for _, it := range tc.items {
+			cur := tc.currency
+			if it.currency != "" {
+				cur = it.currency
+			}
+			o.Items = append(o.Items, Item{
+				Price:      mustMoney(t, it.price, cur),
+				Sale:       it.sale,
+				TotalPrice: mustMoney(t, it.total, cur),
+			})
+		}
+
+		err := o.CheckTotals()
+		if tc.err == nil && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if !errors.Is(err, tc.err) {
+			t.Errorf("%s: got error %v, want %v", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestBindCurrency(t *testing.T) {
+	unbound := func(s string) Money { return mustMoney(t, s, "") }
+	o := &Order{
+		Payment: Payment{
+			Currency:     "KWD",
+			Amount:       unbound("10.266"),
+			DeliveryCost: unbound("1.5"),
+			GoodsTotal:   unbound("8.641"),
+			CustomFee:    unbound("0.125"),
+		},
+		Items: []Item{{Price: unbound("12.345"), Sale: 30, TotalPrice: unbound("8.641")}},
+	}
+	if err := o.BindCurrency(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.CheckTotals(); err != nil {
+		t.Fatal(err)
+	}
+	if o.Items[0].Price != NewMoney(12345, "KWD") {
+		t.Errorf("got price %d %s, want 12345 KWD", o.Items[0].Price.Minor(), o.Items[0].Price.Currency())
+	}
+
+	o.Payment.Currency = "JPY"
+	if err := o.BindCurrency(); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("rebinding KWD to JPY: got error %v, want %v", err, ErrCurrencyMismatch)
+	}
+
+	o = &Order{
+		Payment: Payment{Currency: "JPY", Amount: unbound("18.17")},
+	}
+	if err := o.BindCurrency(); !errors.Is(err, ErrMoneyPrecision) {
+		t.Errorf("cents as JPY: got error %v, want %v", err, ErrMoneyPrecision)
+	}
+
+	o = &Order{Payment: Payment{Amount: unbound("18.17")}}
+	if err := o.BindCurrency(); err != nil || o.Payment.Amount.Currency() != "" {
+		t.Errorf("no currency: got %v, amount in %q, want it unbound", err, o.Payment.Amount.Currency())
+	}
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"fmt"
+	"order-service/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column is a field of a flattened row, one row per item of an order.
+// Item columns are empty for an order without items.
+type Column struct {
+	Name string
+	// Numeric columns are written as numbers to spreadsheets.
+	Numeric bool
+	Value   func(o *domain.Order, it *domain.Item) string
+}
+
+func orderCol(name string, numeric bool, v func(o *domain.Order) string) Column {
+	return Column{name, numeric, func(o *domain.Order, _ *domain.Item) string { return v(o) }}
+}
+
+func itemCol(name string, numeric bool, v func(it *domain.Item) string) Column {
+	return Column{name, numeric, func(_ *domain.Order, it *domain.Item) string {
+		if it == nil {
+			return ""
+		}
+		return v(it)
+	}}
+}
+
+func itoa[T ~int | ~int64](n T) string { return strconv.FormatInt(int64(n), 10) }
+
+// Columns are the flattened columns in their default order.
+var Columns = []Column{
+	orderCol("order_uid", false, func(o *domain.Order) string { return o.OrderId.String() }),
+	orderCol("track_number", false, func(o *domain.Order) string { return o.TrackNumber }),
+	orderCol("entry", false, func(o *domain.Order) string { return o.Entry }),
+	orderCol("locale", false, func(o *domain.Order) string { return o.Locale }),
+	orderCol("internal_signature", false, func(o *domain.Order) string { return o.InternalSignature }),
+	orderCol("customer_id", false, func(o *domain.Order) string { return o.CustomerId }),
+	orderCol("delivery_service", false, func(o *domain.Order) string { return o.DeliveryService }),
+	orderCol("shardkey", true, func(o *domain.Order) string { return itoa(o.ShardKey) }),
+	orderCol("sm_id", true, func(o *domain.Order) string { return itoa(o.SmId) }),
+	orderCol("date_created", false, func(o *domain.Order) string { return o.DateCreated.UTC().Format(time.RFC3339Nano) }),
+	orderCol("status", false, func(o *domain.Order) string { return o.Status }),
+
+	orderCol("delivery_name", false, func(o *domain.Order) string { return o.Delivery.Name }),
+	orderCol("delivery_phone", false, func(o *domain.Order) string { return o.Delivery.Phone }),
+	orderCol("delivery_zip", false, func(o *domain.Order) string { return o.Delivery.Zip }),
+	orderCol("delivery_city", false, func(o *domain.Order) string { return o.Delivery.City }),
+	orderCol("delivery_address", false, func(o *domain.Order) string { return o.Delivery.Address }),
+	orderCol("delivery_region", false, func(o *domain.Order) string { return o.Delivery.Region }),
+	orderCol("delivery_email", false, func(o *domain.Order) string { return o.Delivery.Email }),
+
+	orderCol("payment_transaction_id", false, func(o *domain.Order) string { return o.Payment.TransactionId }),
+	orderCol("payment_request_id", false, func(o *domain.Order) string { return o.Payment.RequestId }),
+	orderCol("payment_currency", false, func(o *domain.Order) string { return o.Payment.Currency }),
+	orderCol("payment_provider", false, func(o *domain.Order) string { return o.Payment.Provider }),
+	orderCol("payment_amount", true, func(o *domain.Order) string { return o.Payment.Amount.String() }),
+	orderCol("payment_dt", true, func(o *domain.Order) string { return itoa(o.Payment.PaymentDt) }),
+	orderCol("payment_bank", false, func(o *domain.Order) string { return o.Payment.Bank }),
+	orderCol("payment_delivery_cost", true, func(o *domain.Order) string { return o.Payment.DeliveryCost.String() }),
+	orderCol("payment_goods_total", true, func(o *domain.Order) string { return o.Payment.GoodsTotal.String() }),
+	orderCol("payment_custom_fee", true, func(o *domain.Order) string { return o.Payment.CustomFee.String() }),
+
+	itemCol("item_chrt_id", true, func(it *domain.Item) string { return itoa(it.ChrtId) }),
+	itemCol("item_track_number", false, func(it *domain.Item) string { return it.TrackNumber }),
+	itemCol("item_price", true, func(it *domain.Item) string { return it.Price.String() }),
+	itemCol("item_rid", false, func(it *domain.Item) string { return it.RID }),
+	itemCol("item_name", false, func(it *domain.Item) string { return it.Name }),
+	itemCol("item_sale", true, func(it *domain.Item) string { return itoa(it.Sale) }),
+	itemCol("item_size", false, func(it *domain.Item) string { return it.Size }),
+	itemCol("item_total_price", true, func(it *domain.Item) string { return it.TotalPrice.String() }),
+	itemCol("item_nm_id", true, func(it *domain.Item) string { return itoa(it.NmID) }),
+	itemCol("item_brand", false, func(it *domain.Item) string { return it.Brand }),
+	itemCol("item_status", false, func(it *domain.Item) string { return it.Status }),
+}
+
+// ParseColumns picks columns by comma separated names, all of them for an
+// empty list.
+func ParseColumns(list string) ([]Column, error) {
+	if strings.TrimSpace(list) == "" {
+		return Columns, nil
+	}
+
+	byName := make(map[string]Column, len(Columns))
+	for _, c := range Columns {
+		byName[c.Name] = c
+	}
+	var cols []Column
+	for _, name := range strings.Split(list, ",") {
+		c, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, strings.TrimSpace(name))
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// rows flattens the order into one row of values per item.
+func rows(o *domain.Order, cols []Column, fn func(values []string) error) error {
+	values := make([]string, len(cols))
+	fill := func(it *domain.Item) error {
+		for i, c := range cols {
+			values[i] = c.Value(o, it)
+		}
+		return fn(values)
+	}
+
+	if len(o.Items) == 0 {
+		return fill(nil)
+	}
+	for i := range o.Items {
+		if err := fill(&o.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
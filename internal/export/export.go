@@ -0,0 +1,136 @@
+// Package export writes order sets as CSV, NDJSON or XLSX. Writers take
+// one order at a time so exports of any size stream in flat memory.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"order-service/internal/domain"
+	"strings"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown export format")
+	ErrUnknownColumn = errors.New("unknown export column")
+)
+
+// Writer encodes orders, Close must be called to complete the output.
+type Writer interface {
+	Write(o *domain.Order) error
+	Close() error
+}
+
+// Options of an export. Columns apply to the flattened formats, NDJSON
+// writes whole orders.
+type Options struct {
+	Columns []Column
+	// Mask replaces delivery PII, see domain.Delivery.Masked.
+	Mask bool
+}
+
+// New returns a writer of format, csv by default.
+func New(format string, w io.Writer, opt Options) (Writer, error) {
+	if opt.Columns == nil {
+		opt.Columns = Columns
+	}
+	switch format {
+	case "", FormatCSV:
+		return newCSV(w, opt), nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w), mask: opt.Mask}, nil
+	case FormatXLSX:
+		return newXLSX(w, opt), nil
+	}
+	return nil, fmt.Errorf("%w %q, want csv, ndjson or xlsx", ErrUnknownFormat, format)
+}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func masked(o *domain.Order, mask bool) *domain.Order {
+	if !mask {
+		return o
+	}
+	m := *o
+	m.Delivery = o.Delivery.Masked()
+	return &m
+}
+
+type ndjsonWriter struct {
+	enc  *json.Encoder
+	mask bool
+}
+
+func (w *ndjsonWriter) Write(o *domain.Order) error {
+	return w.enc.Encode(masked(o, w.mask))
+}
+
+func (w *ndjsonWriter) Close() error { return nil }
+
+type csvWriter struct {
+	w      *csv.Writer
+	opt    Options
+	header bool
+}
+
+func newCSV(w io.Writer, opt Options) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), opt: opt}
+}
+
+func (w *csvWriter) Write(o *domain.Order) error {
+	if !w.header {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return rows(masked(o, w.opt.Mask), w.opt.Columns, w.row)
+}
+
+// row escapes text cells that spreadsheets would run as formulas, such as a
+// delivery address starting with "=". Numeric columns are left alone, a
+// negative amount is not a formula.
+func (w *csvWriter) row(values []string) error {
+	for i, v := range values {
+		if v != "" && !w.opt.Columns[i].Numeric && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			values[i] = "'" + v
+		}
+	}
+	return w.w.Write(values)
+}
+
+func (w *csvWriter) writeHeader() error {
+	w.header = true
+	names := make([]string, len(w.opt.Columns))
+	for i, c := range w.opt.Columns {
+		names[i] = c.Name
+	}
+	return w.w.Write(names)
+}
+
+// Close writes the header of an empty export and flushes.
+func (w *csvWriter) Close() error {
+	if !w.header {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"order-service/internal/domain"
+)
+
+// maxSheetRows is the row limit of a worksheet, the export continues on a
+// new sheet with its own header.
+const maxSheetRows = 1 << 20
+
+// xlsxWriter writes a minimal SpreadsheetML workbook. Sheets are streamed
+// into the zip as rows come, with inline strings instead of a shared
+// strings table that would have to be held in memory; the workbook parts
+// listing the sheets are written on Close.
+type xlsxWriter struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	opt    Options
+	sheets int
+	rows   int
+}
+
+func newXLSX(w io.Writer, opt Options) *xlsxWriter {
+	return &xlsxWriter{zip: zip.NewWriter(w), opt: opt}
+}
+
+func (w *xlsxWriter) Write(o *domain.Order) error {
+	return rows(masked(o, w.opt.Mask), w.opt.Columns, w.row)
+}
+
+func (w *xlsxWriter) row(values []string) error {
+	if w.sheet == nil || w.rows == maxSheetRows {
+		if err := w.nextSheet(); err != nil {
+			return err
+		}
+	}
+	w.rows++
+	w.sheet.WriteString("<row>")
+	for i, v := range values {
+		switch {
+		case v == "":
+			w.sheet.WriteString("<c/>")
+		case w.opt.Columns[i].Numeric:
+			w.sheet.WriteString("<c><v>")
+			xml.EscapeText(w.sheet, []byte(v))
+			w.sheet.WriteString("</v></c>")
+		default:
+			w.inlineStr(v)
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+func (w *xlsxWriter) inlineStr(s string) {
+	w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(w.sheet, []byte(s))
+	w.sheet.WriteString("</t></is></c>")
+}
+
+func (w *xlsxWriter) nextSheet() error {
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	w.sheets++
+	f, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", w.sheets))
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(f)
+	w.sheet.WriteString(xml.Header)
+	w.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	w.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" state="frozen"/></sheetView></sheetViews>`)
+	w.sheet.WriteString("<sheetData><row>")
+	for _, c := range w.opt.Columns {
+		w.inlineStr(c.Name)
+	}
+	w.sheet.WriteString("</row>")
+	w.rows = 1
+	return nil
+}
+
+func (w *xlsxWriter) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	w.sheet.WriteString("</sheetData></worksheet>")
+	return w.sheet.Flush()
+}
+
+// Close ends the last sheet, an empty export gets a sheet with the header
+// only, and writes the workbook parts.
+func (w *xlsxWriter) Close() error {
+	if w.sheet == nil {
+		if err := w.nextSheet(); err != nil {
+			return err
+		}
+	}
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	var sheets, rels, types string
+	for i := 1; i <= w.sheets; i++ {
+		sheets += fmt.Sprintf(`<sheet name="Orders %d" sheetId="%d" r:id="rId%d"/>`, i, i, i)
+		rels += fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+		types += fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+
+	parts := []struct{ name, body string }{
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			sheets + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels + `</Relationships>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types + `</Types>`},
+	}
+	for _, p := range parts {
+		f, err := w.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.body); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
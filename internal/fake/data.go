@@ -0,0 +1,97 @@
+package fake
+
+// region is a delivery region with its cities, the zip prefix of each city
+// and the locale its customers mostly use.
+type region struct {
+	name   string
+	locale string
+	phone  string
+	cities []city
+}
+
+type city struct {
+	name string
+	zip  string
+}
+
+var regions = []region{
+	{"Moscow", "ru", "+79", []city{{"Moscow", "101"}, {"Zelenograd", "124"}, {"Podolsk", "142"}}},
+	{"Saint Petersburg", "ru", "+79", []city{{"Saint Petersburg", "190"}, {"Kolpino", "196"}, {"Pushkin", "196"}}},
+	{"Kazan", "ru", "+79", []city{{"Kazan", "420"}, {"Naberezhnye Chelny", "423"}}},
+	{"Novosibirsk", "ru", "+79", []city{{"Novosibirsk", "630"}, {"Berdsk", "633"}}},
+	{"Minsk", "be", "+375", []city{{"Minsk", "220"}, {"Barysaw", "222"}}},
+	{"Almaty", "kk", "+77", []city{{"Almaty", "050"}, {"Kaskelen", "040"}}},
+	{"Tel Aviv", "he", "+972", []city{{"Tel Aviv", "61"}, {"Kiryat Mozkin", "26"}, {"Haifa", "31"}}},
+	{"Berlin", "de", "+49", []city{{"Berlin", "101"}, {"Potsdam", "144"}}},
+	{"London", "en", "+44", []city{{"London", "EC1"}, {"Croydon", "CR0"}}},
+}
+
+// people holds the names and streets customers of a locale use.
+var people = map[string]struct {
+	first, last, streets []string
+}{
+	"ru": {
+		[]string{"Anna", "Ivan", "Maria", "Dmitry", "Elena", "Sergey", "Olga", "Alexey"},
+		[]string{"Ivanova", "Petrov", "Smirnova", "Kuznetsov", "Popova", "Sokolov"},
+		[]string{"Lenina", "Pushkina", "Sadovaya", "Mira", "Tsentralnaya"},
+	},
+	"be": {
+		[]string{"Alesya", "Yauhen", "Hanna", "Mikita"},
+		[]string{"Kavalenka", "Novik", "Shautsova", "Karpovich"},
+		[]string{"Nezalezhnasci", "Surhanava", "Kalvaryjskaja"},
+	},
+	"kk": {
+		[]string{"Aigerim", "Nursultan", "Dana", "Yerlan"},
+		[]string{"Akhmetova", "Suleimenov", "Omarova", "Zhaksylykov"},
+		[]string{"Abaya", "Dostyk", "Tole Bi", "Furmanova"},
+	},
+	"he": {
+		[]string{"Noa", "Itai", "Tamar", "Yosef"},
+		[]string{"Cohen", "Levi", "Mizrahi", "Peretz"},
+		[]string{"Herzl", "Dizengoff", "Allenby", "Ben Yehuda"},
+	},
+	"de": {
+		[]string{"Lena", "Max", "Eva", "Paul"},
+		[]string{"Muller", "Schmidt", "Schneider", "Fischer"},
+		[]string{"Hauptstrasse", "Schillerstrasse", "Gartenweg"},
+	},
+	"en": {
+		[]string{"Tom", "Sara", "Oliver", "Emily"},
+		[]string{"Smith", "Brown", "Taylor", "Wilson"},
+		[]string{"High Street", "Station Road", "Church Lane"},
+	},
+}
+
+var (
+	domains = []string{"gmail.com", "mail.ru", "yandex.ru", "outlook.com"}
+
+	entries          = []string{"WBIL", "WBMA", "WBRU"}
+	deliveryServices = []string{"meest", "cdek", "boxberry", "russianpost", "dpd"}
+	providers        = []string{"wbpay", "sbp", "yookassa", "stripe"}
+	banks            = []string{"alpha", "sber", "tinkoff", "vtb", "raiffeisen"}
+)
+
+// product is a catalog entry, price ranges of the profile scale its base price.
+type product struct {
+	name  string
+	brand string
+	sizes []string
+	base  int64
+}
+
+var catalog = []product{
+	{"Mascaras", "Vivienne Sabo", []string{"0"}, 450},
+	{"Lipstick", "Maybelline", []string{"0"}, 520},
+	{"T-shirt", "Befree", []string{"XS", "S", "M", "L", "XL"}, 990},
+	{"Jeans", "Levi's", []string{"28", "30", "32", "34", "36"}, 4990},
+	{"Sneakers", "Nike", []string{"38", "39", "40", "41", "42", "43", "44"}, 8990},
+	{"Hoodie", "Adidas", []string{"S", "M", "L", "XL"}, 5490},
+	{"Backpack", "Xiaomi", []string{"0"}, 2490},
+	{"Headphones", "JBL", []string{"0"}, 3990},
+	{"Phone case", "Spigen", []string{"0"}, 890},
+	{"Coffee beans 1kg", "Lavazza", []string{"0"}, 1890},
+	{"Kettle", "Bosch", []string{"0"}, 3290},
+	{"Bed linen set", "Togas", []string{"1.5", "2", "Euro"}, 6490},
+	{"Children's book", "Eksmo", []string{"0"}, 590},
+	{"Vitamin D3", "Solgar", []string{"0"}, 1490},
+}
@@ -0,0 +1,157 @@
+// Package fake generates internally consistent orders for kafkaP, tests and
+// database seeding. Item totals follow price and sale, the payment adds up
+// its goods total, delivery cost and custom fee, and customers, cities and
+// locales belong together.
+package fake
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"math/rand"
+	"order-service/internal/domain"
+	"strings"
+	"time"
+)
+
+// Item statuses used by generated orders.
+const (
+	ItemStatusSold     = "202"
+	ItemStatusReturned = "410"
+)
+
+// Generator draws orders of one profile from a seeded source, the same seed
+// and profile give the same orders apart from their dates. It is not safe
+// for concurrent use.
+type Generator struct {
+	rng     *rand.Rand
+	profile Profile
+	regions []region
+	now     func() time.Time
+}
+
+func New(seed int64, p Profile) (*Generator, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	g := &Generator{rng: rand.New(rand.NewSource(seed)), profile: p, now: time.Now}
+	for _, r := range regions {
+		if len(p.Regions) == 0 || contains(p.Regions, r.name) {
+			g.regions = append(g.regions, r)
+		}
+	}
+	if p.Customers < 1 {
+		g.profile.Customers = 1000
+	}
+	return g, nil
+}
+
+// Order returns the next order.
+func (g *Generator) Order() domain.Order {
+	uid, _ := uuid.NewRandomFromReader(g.rng)
+	track := fmt.Sprintf("WB%s%010d", strings.ToUpper(string(rune('A'+g.rng.Intn(26)))), g.rng.Int63n(1e10))
+
+	// a customer always lives in the same region, under the same name
+	customer := g.rng.Intn(g.profile.Customers)
+	crng := rand.New(rand.NewSource(int64(customer)))
+	reg := g.regions[crng.Intn(len(g.regions))]
+	ct := reg.cities[crng.Intn(len(reg.cities))]
+	folk := people[reg.locale]
+	first, last := pick(crng, folk.first), pick(crng, folk.last)
+
+	ord := domain.Order{
+		OrderId:         uid,
+		TrackNumber:     track,
+		Entry:           pick(g.rng, entries),
+		Locale:          reg.locale,
+		CustomerId:      fmt.Sprintf("customer-%05d", customer),
+		DeliveryService: pick(g.rng, deliveryServices),
+		ShardKey:        int64(g.rng.Intn(10)),
+		SmId:            g.rng.Intn(100),
+		DateCreated:     g.now().UTC().Add(-time.Duration(g.rng.Intn(3600)) * time.Second),
+		Delivery: domain.Delivery{
+			Name:    first + " " + last,
+			Phone:   fmt.Sprintf("%s%09d", reg.phone, crng.Intn(1e9)),
+			Zip:     fmt.Sprintf("%s%03d", ct.zip, crng.Intn(1000)),
+			City:    ct.name,
+			Address: fmt.Sprintf("%s %d", pick(crng, folk.streets), 1+crng.Intn(120)),
+			Region:  reg.name,
+			Email:   strings.ToLower(fmt.Sprintf("%s.%s%d@%s", first, last, customer, pick(crng, domains))),
+		},
+	}
+
+	p := &ord.Payment
+	p.TransactionId = uid.String()
+	p.Currency = g.profile.Currencies.draw(g.rng)
+	for i := g.profile.Items.draw(g.rng); i > 0; i-- {
+		ord.Items = append(ord.Items, g.item(track, p.Currency))
+	}
+
+	p.Provider = pick(g.rng, providers)
+	p.Bank = pick(g.rng, banks)
+	p.PaymentDt = ord.DateCreated.Add(time.Duration(g.rng.Intn(300)) * time.Second).Unix()
+	p.DeliveryCost = domain.MoneyFromMajor(g.profile.DeliveryCost.draw(g.rng), p.Currency)
+	p.CustomFee = domain.NewMoney(0, p.Currency)
+	if g.rng.Float64() < g.profile.CustomFeeChance {
+		p.CustomFee = domain.MoneyFromMajor(g.profile.CustomFee.draw(g.rng), p.Currency)
+	}
+
+	// every amount is in the payment currency, the sums cannot fail
+	totals := make([]domain.Money, len(ord.Items))
+	for i, it := range ord.Items {
+		totals[i] = it.TotalPrice
+	}
+	p.GoodsTotal, _ = domain.Sum(p.Currency, totals...)
+	p.Amount, _ = domain.Sum(p.Currency, p.GoodsTotal, p.DeliveryCost, p.CustomFee)
+	return ord
+}
+
+// Orders returns the next n orders.
+func (g *Generator) Orders(n int) []domain.Order {
+	list := make([]domain.Order, n)
+	for i := range list {
+		list[i] = g.Order()
+	}
+	return list
+}
+
+func (g *Generator) item(track, currency string) domain.Item {
+	prod := catalog[g.rng.Intn(len(catalog))]
+	price := domain.MoneyFromMajor(max(1, int64(float64(prod.base)*g.profile.PriceFactor.draw(g.rng))), currency)
+
+	sale := 0
+	if g.rng.Float64() < g.profile.SaleChance {
+		sale = g.profile.Sale.draw(g.rng)
+	}
+	status := ItemStatusSold
+	if g.rng.Float64() < g.profile.ReturnChance {
+		status = ItemStatusReturned
+	}
+
+	return domain.Item{
+		ChrtId:      1_000_000 + g.rng.Int63n(9_000_000),
+		TrackNumber: track,
+		Price:       price,
+		RID:         strings.ReplaceAll(uuid.Must(uuid.NewRandomFromReader(g.rng)).String(), "-", "")[:21],
+		Name:        prod.name,
+		Sale:        sale,
+		Size:        pick(g.rng, prod.sizes),
+		TotalPrice:  price.Discount(sale),
+		NmID:        1_000_000 + g.rng.Int63n(9_000_000),
+		Brand:       prod.brand,
+		Status:      status,
+	}
+}
+
+func pick(rng *rand.Rand, list []string) string {
+	return list[rng.Intn(len(list))]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBuiltinProfilesAddUp(t *testing.T) {
+	profiles, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) == 0 {
+		t.Fatal("no built-in profiles")
+	}
+
+	for _, p := range profiles {
+		g, err := New(42, p)
+		if err != nil {
+			t.Fatalf("%s: %v", p.Name, err)
+		}
+		for i, o := range g.Orders(500) {
+			if err := o.CheckTotals(); err != nil {
+				raw, _ := json.Marshal(o)
+				t.Fatalf("%s: order %d: %v\n%s", p.Name, i, err, raw)
+			}
+			if len(o.Items) == 0 {
+				t.Fatalf("%s: order %d has no items", p.Name, i)
+			}
+			if p.Currencies[o.Payment.Currency] <= 0 {
+				t.Fatalf("%s: order %d in currency %s the profile does not weigh", p.Name, i, o.Payment.Currency)
+			}
+		}
+	}
+}
+
+func TestGeneratorReproducible(t *testing.T) {
+	p, err := LookupProfile("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	generate := func(seed int64) []byte {
+		g, err := New(seed, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.now = func() time.Time { return now }
+		raw, err := json.Marshal(g.Orders(50))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	a, b := generate(7), generate(7)
+	if !bytes.Equal(a, b) {
+		t.Error("the same seed gave different orders")
+	}
+	if bytes.Equal(a, generate(8)) {
+		t.Error("another seed gave the same orders")
+	}
+}
+
+func TestCustomersComeBack(t *testing.T) {
+	p, err := LookupProfile("", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Customers = 3
+	g, err := New(1, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a customer keeps name, region and locale across orders
+	seen := map[string][3]string{}
+	for _, o := range g.Orders(100) {
+		who := [3]string{o.Delivery.Name, o.Delivery.Region, o.Locale}
+		if prev, ok := seen[o.CustomerId]; ok && prev != who {
+			t.Fatalf("customer %s was %v, now %v", o.CustomerId, prev, who)
+		}
+		seen[o.CustomerId] = who
+	}
+	if len(seen) > 3 {
+		t.Errorf("got %d customers, the pool has 3", len(seen))
+	}
+}
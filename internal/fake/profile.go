@@ -0,0 +1,161 @@
+package fake
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+)
+
+//go:embed profiles.yaml
+var builtinProfiles []byte
+
+// Profile shapes a scenario of generated orders, see profiles.yaml.
+type Profile struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+
+	// Items weighs the number of items per order.
+	Items Weights[int] `yaml:"items"`
+	// PriceFactor scales catalog prices.
+	PriceFactor     Range[float64]  `yaml:"price_factor"`
+	SaleChance      float64         `yaml:"sale_chance"`
+	Sale            Range[int]      `yaml:"sale"`
+	ReturnChance    float64         `yaml:"return_chance"`
+	Currencies      Weights[string] `yaml:"currencies"`
+	Regions         []string        `yaml:"regions"`
+	DeliveryCost    Range[int64]    `yaml:"delivery_cost"`
+	CustomFeeChance float64         `yaml:"custom_fee_chance"`
+	CustomFee       Range[int64]    `yaml:"custom_fee"`
+	// Customers is the size of the customer pool, so customers come back.
+	Customers int `yaml:"customers"`
+}
+
+// Weights picks keys in proportion to their weights.
+type Weights[K cmp.Ordered] map[K]int
+
+func (w Weights[K]) draw(rng *rand.Rand) K {
+	keys := make([]K, 0, len(w))
+	total := 0
+	for k, weight := range w {
+		keys = append(keys, k)
+		total += weight
+	}
+	// map order is random, sort to keep a seed reproducible
+	slices.Sort(keys)
+
+	x := rng.Intn(total)
+	for _, k := range keys {
+		if x < w[k] {
+			return k
+		}
+		x -= w[k]
+	}
+	return keys[len(keys)-1]
+}
+
+type Range[T int | int64 | float64] struct {
+	Min T `yaml:"min"`
+	Max T `yaml:"max"`
+}
+
+func (r Range[T]) draw(rng *rand.Rand) T {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+	return r.Min + T(rng.Float64()*float64(r.Max-r.Min))
+}
+
+func (p Profile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile without a name")
+	}
+	for name, w := range map[string]int{"items": positive(p.Items), "currencies": positive(p.Currencies)} {
+		if w == 0 {
+			return fmt.Errorf("profile %s: %s need at least one positive weight", p.Name, name)
+		}
+	}
+	for k, w := range p.Items {
+		if k < 1 || w < 0 {
+			return fmt.Errorf("profile %s: bad items weight %d: %d", p.Name, k, w)
+		}
+	}
+	for c, w := range p.Currencies {
+		if len(c) != 3 {
+			return fmt.Errorf("profile %s: currency %q is not a 3-letter code", p.Name, c)
+		}
+		if w < 0 {
+			return fmt.Errorf("profile %s: bad currencies weight %s: %d", p.Name, c, w)
+		}
+	}
+	for _, name := range p.Regions {
+		if !slices.ContainsFunc(regions, func(r region) bool { return r.name == name }) {
+			return fmt.Errorf("profile %s: unknown region %q", p.Name, name)
+		}
+	}
+	if p.Sale.Min < 0 || p.Sale.Max > 99 {
+		return fmt.Errorf("profile %s: sale must stay within 0-99 percent", p.Name)
+	}
+	return nil
+}
+
+func positive[K cmp.Ordered](w Weights[K]) int {
+	total := 0
+	for _, weight := range w {
+		if weight > 0 {
+			total += weight
+		}
+	}
+	return total
+}
+
+// LoadProfiles reads a YAML list of profiles.
+func LoadProfiles(r io.Reader) ([]Profile, error) {
+	var list []Profile
+	if err := yaml.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("profiles: %w", err)
+	}
+	for _, p := range list {
+		if err := p.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// LookupProfile finds a profile by name in file, or among the built-in
+// profiles when file is empty.
+func LookupProfile(file, name string) (Profile, error) {
+	var list []Profile
+	var err error
+	if file == "" {
+		list, err = Builtin()
+	} else {
+		var f *os.File
+		if f, err = os.Open(file); err != nil {
+			return Profile{}, err
+		}
+		defer f.Close()
+		list, err = LoadProfiles(f)
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+
+	for _, p := range list {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("no profile %q", name)
+}
+
+// Builtin returns the profiles shipped with the package.
+func Builtin() ([]Profile, error) {
+	return LoadProfiles(bytes.NewReader(builtinProfiles))
+}
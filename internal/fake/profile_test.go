@@ -0,0 +1,51 @@
+package fake
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfilesRejects(t *testing.T) {
+	const valid = `
+- name: test
+  items: {1: 1}
+  currencies: {USD: 1}
+`
+	if _, err := LoadProfiles(strings.NewReader(valid)); err != nil {
+		t.Fatalf("valid profile: %v", err)
+	}
+
+	for name, yaml := range map[string]string{
+		"no name":                `[{items: {1: 1}, currencies: {USD: 1}}]`,
+		"no items":               `[{name: x, currencies: {USD: 1}}]`,
+		"no currencies":          `[{name: x, items: {1: 1}}]`,
+		"zero items":             `[{name: x, items: {0: 1}, currencies: {USD: 1}}]`,
+		"negative items weight":  `[{name: x, items: {1: 1, 2: -1}, currencies: {USD: 1}}]`,
+		"negative currency":      `[{name: x, items: {1: 1}, currencies: {USD: 1, EUR: -5}}]`,
+		"only negative currency": `[{name: x, items: {1: 1}, currencies: {EUR: -5}}]`,
+		"bad currency code":      `[{name: x, items: {1: 1}, currencies: {EURO: 1}}]`,
+		"unknown region":         `[{name: x, items: {1: 1}, currencies: {USD: 1}, regions: [Atlantis]}]`,
+		"sale out of range":      `[{name: x, items: {1: 1}, currencies: {USD: 1}, sale: {min: 10, max: 100}}]`,
+		"not a list":             `name: x`,
+	} {
+		if _, err := LoadProfiles(strings.NewReader(yaml)); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+func TestWeightsDraw(t *testing.T) {
+	w := Weights[string]{"EUR": 1, "RUB": 0, "USD": 3}
+	rng := rand.New(rand.NewSource(1))
+	counts := map[string]int{}
+	for range 4000 {
+		counts[w.draw(rng)]++
+	}
+	if counts["RUB"] != 0 {
+		t.Errorf("zero weight was drawn %d times", counts["RUB"])
+	}
+	if counts["USD"] < 2*counts["EUR"] {
+		t.Errorf("got %v, want USD about three times as often as EUR", counts)
+	}
+}
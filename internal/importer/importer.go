@@ -0,0 +1,268 @@
+// Package importer reads order dumps for bulk imports: NDJSON with an order
+// per line, or CSV in the flattened layout of package export with a row per
+// item. Orders come out as JSON, to be validated by the same decoder as
+// Kafka messages.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"order-service/internal/domain"
+	"order-service/internal/export"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var ErrUnknownFormat = errors.New("unknown import format, want csv or ndjson")
+
+// Formats lists the formats New reads.
+var Formats = []string{export.FormatCSV, export.FormatNDJSON}
+
+// Reader reads the orders of a file one at a time. Rows are numbered from
+// 1: lines of NDJSON, records of CSV counting the header.
+type Reader interface {
+	// Next returns the next order, io.EOF at the end of the file. Orders
+	// that cannot be read carry their error, the file goes on after them.
+	Next() (domain.ImportRecord, error)
+	// Skip passes over the rows up to row, a resumed job starts after the
+	// rows it has done. It must be called before Next.
+	Skip(row int64) error
+}
+
+// New returns a reader of format, see FormatOf.
+func New(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case export.FormatNDJSON:
+		return &ndjsonReader{r: bufio.NewReader(r)}, nil
+	case export.FormatCSV:
+		return newCSV(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// FormatOf guesses the format from a file name, empty when unknown.
+func FormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return export.FormatCSV
+	case ".ndjson", ".jsonl", ".json":
+		return export.FormatNDJSON
+	}
+	return ""
+}
+
+type ndjsonReader struct {
+	r   *bufio.Reader
+	row int64
+}
+
+func (n *ndjsonReader) line() ([]byte, error) {
+	line, err := n.r.ReadBytes('\n')
+	if len(line) > 0 {
+		n.row++
+		return line, nil
+	}
+	return nil, err
+}
+
+func (n *ndjsonReader) Next() (domain.ImportRecord, error) {
+	for {
+		line, err := n.line()
+		if err != nil {
+			return domain.ImportRecord{}, err
+		}
+		// blank lines count as rows but hold no order
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return domain.ImportRecord{Row: n.row, End: n.row, Data: line}, nil
+		}
+	}
+}
+
+func (n *ndjsonReader) Skip(row int64) error {
+	for n.row < row {
+		if _, err := n.line(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvReader groups consecutive rows of one order_uid into an order.
+type csvReader struct {
+	r       *csv.Reader
+	cols    []export.Column
+	row     int64
+	uidCol  int
+	pending []string
+}
+
+func newCSV(r io.Reader) (*csvReader, error) {
+	c := &csvReader{r: csv.NewReader(r)}
+	c.r.FieldsPerRecord = -1
+
+	header, err := c.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	c.row = 1
+	if c.cols, err = export.ParseColumns(strings.Join(header, ",")); err != nil {
+		return nil, fmt.Errorf("csv header: %w", err)
+	}
+	c.uidCol = -1
+	for i, col := range c.cols {
+		if col.Name == "order_uid" {
+			c.uidCol = i
+		}
+	}
+	if c.uidCol < 0 {
+		return nil, errors.New("csv header: no order_uid column")
+	}
+	return c, nil
+}
+
+func (c *csvReader) read() ([]string, error) {
+	if c.pending != nil {
+		rec := c.pending
+		c.pending = nil
+		return rec, nil
+	}
+	rec, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	c.row++
+	return rec, nil
+}
+
+func (c *csvReader) Skip(row int64) error {
+	for c.row < row {
+		if _, err := c.read(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvReader) Next() (domain.ImportRecord, error) {
+	first, err := c.read()
+	if err != nil {
+		return domain.ImportRecord{}, err
+	}
+	rec := domain.ImportRecord{Row: c.row, End: c.row}
+	group := [][]string{first}
+	for len(first) == len(c.cols) {
+		next, err := c.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return domain.ImportRecord{}, err
+		}
+		if len(next) != len(c.cols) || next[c.uidCol] != first[c.uidCol] {
+			c.pending = next
+			break
+		}
+		group = append(group, next)
+		rec.End = c.row
+	}
+
+	if len(first) != len(c.cols) {
+		rec.Err = fmt.Errorf("%d fields, the header has %d", len(first), len(c.cols))
+		return rec, nil
+	}
+	rec.Data, rec.Err = c.order(group)
+	return rec, nil
+}
+
+var (
+	deliveryFields = jsonFields[domain.Delivery]()
+	paymentFields  = jsonFields[domain.Payment]()
+	itemFields     = jsonFields[domain.Item]()
+)
+
+func jsonFields[T any]() map[string]bool {
+	t := reflect.TypeFor[T]()
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	return fields
+}
+
+// nested reports whether the column is a field of the nested object named
+// by prefix, delivery_service is a field of the order.
+func nested(name, prefix string, fields map[string]bool) bool {
+	field, ok := strings.CutPrefix(name, prefix)
+	return ok && fields[field]
+}
+
+// unescape drops the quote the export puts before text that spreadsheets
+// would run as a formula, so an exported phone "+7..." comes back as is.
+func unescape(v string) string {
+	if len(v) > 1 && v[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(v[1])) {
+		return v[1:]
+	}
+	return v
+}
+
+// order builds the JSON order from its rows. Column names are the JSON
+// fields, prefixed with delivery_, payment_ or item_ for the nested ones.
+// Empty values are left out.
+func (c *csvReader) order(group [][]string) ([]byte, error) {
+	order := map[string]any{}
+	delivery := map[string]any{}
+	payment := map[string]any{}
+	items := make([]map[string]any, 0, len(group))
+
+	for r, values := range group {
+		item := map[string]any{}
+		for i, col := range c.cols {
+			v := values[i]
+			if v == "" {
+				continue
+			}
+			if !col.Numeric {
+				v = unescape(v)
+			}
+			var value any = v
+			if col.Numeric {
+				if _, err := strconv.ParseFloat(v, 64); err != nil {
+					return nil, fmt.Errorf("%s: %q is not a number", col.Name, v)
+				}
+				value = json.Number(v)
+			}
+
+			switch name := col.Name; {
+			case nested(name, "item_", itemFields):
+				item[strings.TrimPrefix(name, "item_")] = value
+			case r > 0:
+				// order fields are taken from the first row
+			case nested(name, "delivery_", deliveryFields):
+				delivery[strings.TrimPrefix(name, "delivery_")] = value
+			case nested(name, "payment_", paymentFields):
+				payment[strings.TrimPrefix(name, "payment_")] = value
+			case paymentFields[name]:
+				// payment_dt is named so in the payment itself
+				payment[name] = value
+			default:
+				order[name] = value
+			}
+		}
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	order["delivery"] = delivery
+	order["payment"] = payment
+	order["items"] = items
+	return json.Marshal(order)
+}
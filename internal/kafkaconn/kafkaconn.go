@@ -0,0 +1,193 @@
+// Package kafkaconn builds the connection settings shared by every Kafka
+// reader, writer and client of the service and of kafkaP.
+package kafkaconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MechanismPlain    = "PLAIN"
+	MechanismSCRAM256 = "SCRAM-SHA-256"
+	MechanismSCRAM512 = "SCRAM-SHA-512"
+
+	dialTimeout = 10 * time.Second
+)
+
+var ErrUnknownMechanism = errors.New("unknown SASL mechanism")
+
+type Config struct {
+	Brokers []string
+
+	// TLS is turned on by TLS itself, by any of the certificate files or by
+	// InsecureSkipVerify. CAFile verifies the brokers instead of the system pool, CertFile and
+	// KeyFile authenticate the client. InsecureSkipVerify is for local
+	// clusters with self-signed certificates only.
+	TLS                bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+
+	// SASLMechanism is PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or empty for none.
+	SASLMechanism string
+	Username      string
+	Password      string
+}
+
+// FromEnv reads KAFKA_BROKERS, KAFKA_TLS*, and KAFKA_SASL_* variables.
+func FromEnv() Config {
+	return Config{
+		Brokers:            splitBrokers(os.Getenv("KAFKA_BROKERS")),
+		TLS:                envBool("KAFKA_TLS"),
+		CAFile:             os.Getenv("KAFKA_TLS_CA"),
+		CertFile:           os.Getenv("KAFKA_TLS_CERT"),
+		KeyFile:            os.Getenv("KAFKA_TLS_KEY"),
+		InsecureSkipVerify: envBool("KAFKA_TLS_SKIP_VERIFY"),
+		SASLMechanism:      os.Getenv("KAFKA_SASL_MECHANISM"),
+		Username:           os.Getenv("KAFKA_SASL_USERNAME"),
+		Password:           os.Getenv("KAFKA_SASL_PASSWORD"),
+	}
+}
+
+// Conn is a resolved Config, ready to be handed to readers, writers and clients.
+type Conn struct {
+	Brokers   []string
+	Dialer    *kafka.Dialer
+	Transport *kafka.Transport
+}
+
+// Connect loads certificates and prepares the SASL mechanism, it does not
+// contact the brokers.
+func (c Config) Connect() (*Conn, error) {
+	if len(c.Brokers) == 0 {
+		return nil, errors.New("no kafka brokers")
+	}
+
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := c.mechanism()
+	if err != nil {
+		return nil, err
+	}
+	if mechanism != nil && tlsCfg == nil {
+		log.Printf("⚠️  kafka SASL %s without TLS sends credentials in the clear", mechanism.Name())
+	}
+
+	return &Conn{
+		Brokers: c.Brokers,
+		Dialer: &kafka.Dialer{
+			Timeout:       dialTimeout,
+			DualStack:     true,
+			TLS:           tlsCfg,
+			SASLMechanism: mechanism,
+		},
+		Transport: &kafka.Transport{
+			DialTimeout: dialTimeout,
+			TLS:         tlsCfg,
+			SASL:        mechanism,
+		},
+	}, nil
+}
+
+// Writer returns a writer for topic that waits for all in-sync replicas.
+func (c *Conn) Writer(topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(c.Brokers...),
+		Topic:        topic,
+		RequiredAcks: kafka.RequireAll,
+		Transport:    c.Transport,
+	}
+}
+
+func (c *Conn) Client() *kafka.Client {
+	return &kafka.Client{
+		Addr:      kafka.TCP(c.Brokers...),
+		Timeout:   dialTimeout,
+		Transport: c.Transport,
+	}
+}
+
+func (c Config) tlsConfig() (*tls.Config, error) {
+	if !c.TLS && c.CAFile == "" && c.CertFile == "" && c.KeyFile == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafka CA: no certificates in %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if c.InsecureSkipVerify {
+		log.Println("⚠️  kafka TLS certificate verification is off")
+	}
+	return cfg, nil
+}
+
+func (c Config) mechanism() (sasl.Mechanism, error) {
+	switch strings.ToUpper(c.SASLMechanism) {
+	case "":
+		return nil, nil
+	case MechanismPlain:
+		return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+	case MechanismSCRAM256:
+		return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+	case MechanismSCRAM512:
+		return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+	}
+	return nil, fmt.Errorf("%w %q, want %s, %s or %s", ErrUnknownMechanism, c.SASLMechanism, MechanismPlain, MechanismSCRAM256, MechanismSCRAM512)
+}
+
+func splitBrokers(s string) []string {
+	var brokers []string
+	for _, b := range strings.Split(s, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			brokers = append(brokers, b)
+		}
+	}
+	return brokers
+}
+
+func envBool(key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("⚠️  bad %s=%q, using false", key, v)
+		return false
+	}
+	return b
+}
@@ -0,0 +1,223 @@
+package kafkaconn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type certFiles struct {
+	ca, cert, key, otherKey string
+	clientDER               []byte
+}
+
+// writeCerts makes a self-signed CA and a client certificate it signs,
+// plus a key that does not match the certificate.
+func writeCerts(t *testing.T) certFiles {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey := newKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test kafka CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey := newKey(t)
+	clientTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "order-service"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTmpl, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := certFiles{
+		ca:        filepath.Join(dir, "ca.pem"),
+		cert:      filepath.Join(dir, "client.pem"),
+		key:       filepath.Join(dir, "client-key.pem"),
+		otherKey:  filepath.Join(dir, "other-key.pem"),
+		clientDER: clientDER,
+	}
+	writePEM(t, f.ca, "CERTIFICATE", caDER)
+	writePEM(t, f.cert, "CERTIFICATE", clientDER)
+	writePEM(t, f.key, "EC PRIVATE KEY", marshalKey(t, clientKey))
+	writePEM(t, f.otherKey, "EC PRIVATE KEY", marshalKey(t, newKey(t)))
+	return f
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func marshalKey(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	f := writeCerts(t)
+
+	cfg, err := Config{CAFile: f.ca, CertFile: f.cert, KeyFile: f.key}.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg == nil {
+		t.Fatal("certificate files did not turn TLS on")
+	}
+	if cfg.InsecureSkipVerify {
+		t.Error("verification is off")
+	}
+
+	if len(cfg.Certificates) != 1 || !bytes.Equal(cfg.Certificates[0].Certificate[0], f.clientDER) {
+		t.Fatal("client certificate is not loaded")
+	}
+	// the pool holds our CA and nothing else, the client certificate chains to it
+	client, err := x509.ParseCertificate(f.clientDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Verify(x509.VerifyOptions{Roots: cfg.RootCAs, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		t.Errorf("client certificate does not verify against the CA pool: %v", err)
+	}
+}
+
+func TestTLSConfigSwitch(t *testing.T) {
+	f := writeCerts(t)
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		on   bool
+	}{
+		{"nothing", Config{}, false},
+		{"tls", Config{TLS: true}, true},
+		{"ca only", Config{CAFile: f.ca}, true},
+		{"skip verify only", Config{InsecureSkipVerify: true}, true},
+	} {
+		cfg, err := tc.cfg.tlsConfig()
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if (cfg != nil) != tc.on {
+			t.Errorf("%s: TLS on is %t, want %t", tc.name, cfg != nil, tc.on)
+		}
+		if cfg != nil && cfg.InsecureSkipVerify != tc.cfg.InsecureSkipVerify {
+			t.Errorf("%s: InsecureSkipVerify is %t", tc.name, cfg.InsecureSkipVerify)
+		}
+	}
+}
+
+func TestTLSConfigBadFiles(t *testing.T) {
+	f := writeCerts(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range map[string]Config{
+		"missing CA":       {CAFile: filepath.Join(t.TempDir(), "nope.pem")},
+		"CA without PEM":   {CAFile: garbage},
+		"key is not PEM":   {CertFile: f.cert, KeyFile: garbage},
+		"cert without key": {CertFile: f.cert},
+		"key without cert": {KeyFile: f.key},
+		"mismatched key":   {CertFile: f.cert, KeyFile: f.otherKey},
+	} {
+		if _, err := c.tlsConfig(); err == nil {
+			t.Errorf("%s was accepted", name)
+		}
+	}
+}
+
+func TestConnect(t *testing.T) {
+	if _, err := (Config{}).Connect(); err == nil {
+		t.Error("no brokers was accepted")
+	}
+
+	_, err := Config{Brokers: []string{"kafka:9092"}, SASLMechanism: "GSSAPI"}.Connect()
+	if !errors.Is(err, ErrUnknownMechanism) {
+		t.Errorf("GSSAPI: error %v, want ErrUnknownMechanism", err)
+	}
+
+	for _, m := range []string{MechanismPlain, "scram-sha-256", MechanismSCRAM512} {
+		conn, err := Config{Brokers: []string{"kafka:9092"}, TLS: true, SASLMechanism: m, Username: "u", Password: "p"}.Connect()
+		if err != nil {
+			t.Errorf("%s: %v", m, err)
+			continue
+		}
+		if conn.Dialer.SASLMechanism == nil || conn.Transport.SASL == nil {
+			t.Errorf("%s: no SASL mechanism", m)
+		}
+		if conn.Dialer.TLS == nil || conn.Dialer.TLS != conn.Transport.TLS {
+			t.Errorf("%s: dialer and transport do not share the TLS config", m)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", " kafka-1:9093, ,kafka-2:9093 ")
+	t.Setenv("KAFKA_TLS", "")
+	t.Setenv("KAFKA_TLS_CA", "")
+	t.Setenv("KAFKA_TLS_CERT", "")
+	t.Setenv("KAFKA_TLS_KEY", "")
+	t.Setenv("KAFKA_TLS_SKIP_VERIFY", "true")
+	t.Setenv("KAFKA_SASL_MECHANISM", "")
+
+	c := FromEnv()
+	if len(c.Brokers) != 2 || c.Brokers[0] != "kafka-1:9093" || c.Brokers[1] != "kafka-2:9093" {
+		t.Errorf("got brokers %q", c.Brokers)
+	}
+	conn, err := c.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Dialer.TLS == nil || !conn.Dialer.TLS.InsecureSkipVerify {
+		t.Error("KAFKA_TLS_SKIP_VERIFY alone did not turn TLS on")
+	}
+}
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "order_service"
+
+var HTTPRateLimited = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "http",
+	Name:      "rate_limited_total",
+	Help:      "Requests rejected by the per-client rate limiter.",
+})
+
+var StreamSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "stream",
+	Name:      "subscribers",
+	Help:      "Live order feed subscribers currently connected.",
+})
+
+var StreamDropped = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "stream",
+	Name:      "dropped_subscribers_total",
+	Help:      "Live order feed subscribers dropped for falling behind.",
+})
+
+var WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "webhook",
+	Name:      "deliveries_total",
+	Help:      "Webhook delivery attempts by outcome.",
+}, []string{"result"})
+
+var WebhookBreakerOpened = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "webhook",
+	Name:      "breaker_opened_total",
+	Help:      "Times a webhook endpoint circuit breaker opened.",
+})
+
+var RelayPublished = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "outbox_relay",
+	Name:      "published_total",
+	Help:      "Outbox events published to Kafka.",
+})
+
+var RelayErrors = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "outbox_relay",
+	Name:      "errors_total",
+	Help:      "Failed outbox relay batches.",
+})
+
+var RelayBatchSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "outbox_relay",
+	Name:      "batch_seconds",
+	Help:      "Time to publish and mark one outbox batch.",
+	Buckets:   prometheus.DefBuckets,
+})
+
+var RelayLag = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "outbox_relay",
+	Name:      "lag_seconds",
+	Help:      "Age of the oldest unpublished outbox event.",
+})
+
+var ConsumerMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "messages_total",
+	Help:      "Kafka messages handled by the consumer by topic and result.",
+}, []string{"topic", "result"})
+
+var ConsumerBatchFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "batch_fallbacks_total",
+	Help:      "Batches that failed and were handled message by message instead, by topic.",
+}, []string{"topic"})
+
+var ConsumerPaused = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "paused",
+	Help:      "1 while the consumer does not fetch, manually or because the database is unhealthy.",
+})
+
+var ConsumerPauses = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "pauses_total",
+	Help:      "Consumer pauses by cause: manual, health or error_rate.",
+}, []string{"cause"})
+
+var ConsumerCommittedOffset = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "committed_offset",
+	Help:      "Next offset to read after a restart, per assigned partition.",
+}, []string{"topic", "partition"})
+
+var ConsumerHighWatermark = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "high_watermark",
+	Help:      "Offset of the next message produced to the partition, per assigned partition.",
+}, []string{"topic", "partition"})
+
+var ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "lag",
+	Help:      "Messages between the committed offset and the high watermark, per assigned partition.",
+}, []string{"topic", "partition"})
+
+var ConsumerLastMessage = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "last_message_timestamp_seconds",
+	Help:      "Kafka timestamp of the last processed message, per assigned partition.",
+}, []string{"topic", "partition"})
+
+var ConsumerDegraded = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "degraded",
+	Help:      "1 while the total lag stays above the alert threshold.",
+})
+
+var ConsumerUnknownFields = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "consumer",
+	Name:      "unknown_fields_total",
+	Help:      "Unknown JSON fields ignored in lenient mode, by producer.",
+}, []string{"producer"})
+
+var AnalyticsRefreshDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "analytics",
+	Name:      "refresh_duration_seconds",
+	Help:      "Time to refresh the analytics materialized views.",
+	Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+})
+
+var AnalyticsLastRefresh = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "analytics",
+	Name:      "last_refresh_timestamp_seconds",
+	Help:      "Time of the last analytics views refresh done by this instance.",
+})
+
+var ImportOrders = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "import",
+	Name:      "orders_total",
+	Help:      "Orders read by bulk import jobs by result: ok, skipped, invalid or failed.",
+}, []string{"result"})
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"order-service/internal/domain"
+)
+
+type AnalyticsRepository interface {
+	// Sales groups orders per period by one of domain.AnalyticsDimensions.
+	Sales(ctx context.Context, q domain.AnalyticsQuery, dimension, period string) ([]domain.SalesRow, error)
+	// TopSales ranks brands or nm_ids by revenue.
+	TopSales(ctx context.Context, q domain.AnalyticsQuery, by string, limit int) ([]domain.TopRow, error)
+	Summary(ctx context.Context, q domain.AnalyticsQuery) (domain.SalesSummary, error)
+	// RefreshAnalytics recomputes the analytics views, it reports false when
+	// another instance is already refreshing them.
+	RefreshAnalytics(ctx context.Context) (bool, error)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"order-service/internal/domain"
+	"time"
+)
+
+type ImportRepository interface {
+	CreateImportJob(ctx context.Context, j *domain.ImportJob) error
+	FindImportJob(ctx context.Context, id int64) (*domain.ImportJob, error)
+	ListImportJobs(ctx context.Context, limit int) ([]*domain.ImportJob, error)
+	// ImportErrors lists row errors of a job after the given row.
+	ImportErrors(ctx context.Context, id, afterRow int64, limit int) ([]domain.ImportRowError, error)
+
+	// ClaimImportJob takes a pending job, or a running one not updated for
+	// the lease, and marks it running under claim. A zero id takes the oldest
+	// such job. It returns nil when there is none.
+	ClaimImportJob(ctx context.Context, id int64, lease time.Duration, claim uuid.UUID) (*domain.ImportJob, error)
+	// RenewImportLease keeps the claim of a running job alive, it reports
+	// false when the job is no longer running under that claim.
+	RenewImportLease(ctx context.Context, id int64, claim uuid.UUID) (bool, error)
+	// SaveImportProgress stores the counters and status of the job together
+	// with new row errors, which also keeps the claim alive. It fails with
+	// domain.ErrImportLost when the job is no longer held by j.Claim, a job
+	// leaving the running status gives up its claim.
+	SaveImportProgress(ctx context.Context, j *domain.ImportJob, errs []domain.ImportRowError) error
+	// RequeueImportJob puts a failed job back to pending, it reports false for
+	// unknown jobs and jobs in another status.
+	RequeueImportJob(ctx context.Context, id int64) (bool, error)
+}
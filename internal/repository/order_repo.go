@@ -2,11 +2,36 @@ package repository
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"order-service/internal/domain"
 )
 
 type OrderRepository interface {
 	Find(ctx context.Context, id string) (*domain.Order, error)
 	Save(ctx context.Context, order *domain.Order) error
+	// SaveBatch stores many orders in one transaction, orders already stored
+	// are skipped. It returns the ids of the orders it inserted.
+	SaveBatch(ctx context.Context, orders []*domain.Order) ([]uuid.UUID, error)
+	// Upsert stores the order or overwrites the stored one, it reports whether the order was new.
+	// The delivery of an order erased on request of its customer is erased in order before it is stored.
+	Upsert(ctx context.Context, order *domain.Order) (bool, error)
+	// PersonalDataErased reports whether the customer had their data erased
+	// after the order was created.
+	PersonalDataErased(ctx context.Context, order *domain.Order) (bool, error)
 	CacheRestore(ctx context.Context) ([]*domain.Order, error)
+	FindRecent(ctx context.Context, limit int) ([]*domain.Order, error)
+	FindByCustomer(ctx context.Context, customerID string) ([]*domain.Order, error)
+	// FindRecentByCustomer returns the latest orders of the customer, newest first.
+	FindRecentByCustomer(ctx context.Context, customerID string, limit int) ([]*domain.Order, error)
+	// ErasePersonalData anonymizes deliveries of the customer, also in the
+	// events of their orders, and records the erasure in the audit table. It returns ids of the affected orders.
+	ErasePersonalData(ctx context.Context, customerID string) ([]string, error)
+	// ConfirmPayment overwrites the stored payment, it reports false for unknown orders.
+	ConfirmPayment(ctx context.Context, c domain.PaymentConfirmation) (bool, error)
+	// UpdateStatus sets the order status, it reports false for unknown orders and stale updates.
+	UpdateStatus(ctx context.Context, u domain.StatusUpdate) (bool, error)
+	// ExportOrders streams the orders matching the filter to fn in
+	// date_created order, reading them through a cursor. An error from fn
+	// stops the export and is returned.
+	ExportOrders(ctx context.Context, f domain.ExportFilter, fn func(*domain.Order) error) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"order-service/internal/domain"
+	"time"
+)
+
+type OutboxRepository interface {
+	// RelayOutbox hands the oldest unpublished events to publish in id order and
+	// marks them published once it returns nil. Only one caller at a time gets
+	// events, so per-order ordering survives several running instances.
+	RelayOutbox(ctx context.Context, limit int, publish func([]*domain.OutboxEvent) error) (int, error)
+	// ListenOutbox signals when new events were committed. The channel is closed with ctx.
+	ListenOutbox(ctx context.Context) <-chan struct{}
+	// CleanupOutbox removes events already published and dispatched to webhooks.
+	CleanupOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+	OldestUnpublished(ctx context.Context) (time.Duration, error)
+}
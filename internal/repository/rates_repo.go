@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"order-service/internal/domain"
+	"time"
+)
+
+type RateRepository interface {
+	// SaveRates stores the rates, a rate already stored for the currency and
+	// date is overwritten. It returns the number of rates stored.
+	SaveRates(ctx context.Context, rates []domain.ExchangeRate) (int, error)
+	// FindRate returns the latest rate of the currency on or before the date,
+	// nil when there is none.
+	FindRate(ctx context.Context, currency string, on time.Time) (*domain.ExchangeRate, error)
+}
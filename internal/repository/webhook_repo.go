@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"order-service/internal/domain"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, s *domain.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) (bool, error)
+
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error)
+	// ReplayDelivery puts a delivery back into the queue with a fresh attempt budget.
+	ReplayDelivery(ctx context.Context, id int64) (bool, error)
+
+	// DispatchOutbox turns new outbox events into deliveries for matching
+	// active subscriptions and returns the number of events handled.
+	DispatchOutbox(ctx context.Context, limit int) (int, error)
+	// ClaimDeliveries leases due deliveries so other instances skip them until the lease expires.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, d *domain.WebhookDelivery) error
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"order-service/internal/domain"
+	"order-service/internal/metrics"
+	"order-service/internal/repository"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBadAnalyticsQuery = errors.New("bad analytics query")
+
+const (
+	DefaultAnalyticsDays = 30
+	MaxAnalyticsDays     = 366
+	DefaultTopLimit      = 10
+	MaxTopLimit          = 100
+)
+
+type cachedResult struct {
+	value   any
+	expires time.Time
+}
+
+// AnalyticsUC serves aggregates from the analytics views, valued in the
+// base currency. Results are cached until the TTL runs out or the views are
+// refreshed by this instance, expired ones are evicted on the next store.
+type AnalyticsUC struct {
+	repo repository.AnalyticsRepository
+	base string
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedResult
+}
+
+func NewAnalyticsUC(r repository.AnalyticsRepository, base string, ttl time.Duration) *AnalyticsUC {
+	return &AnalyticsUC{repo: r, base: strings.ToUpper(base), ttl: ttl, cache: make(map[string]cachedResult)}
+}
+
+// Query builds the query of days from to to, either may be zero: to
+// defaults to today and from to DefaultAnalyticsDays before to.
+func (uc *AnalyticsUC) Query(from, to time.Time) (domain.AnalyticsQuery, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	to = day(to)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -DefaultAnalyticsDays+1)
+	}
+	from = day(from)
+
+	switch {
+	case from.After(to):
+		return domain.AnalyticsQuery{}, fmt.Errorf("%w: from is after to", ErrBadAnalyticsQuery)
+	case to.Sub(from) >= MaxAnalyticsDays*24*time.Hour:
+		return domain.AnalyticsQuery{}, fmt.Errorf("%w: range is longer than %d days", ErrBadAnalyticsQuery, MaxAnalyticsDays)
+	}
+	return domain.AnalyticsQuery{From: from, To: to, Currency: uc.base}, nil
+}
+
+// Sales groups orders per day or week by one of domain.AnalyticsDimensions.
+func (uc *AnalyticsUC) Sales(ctx context.Context, q domain.AnalyticsQuery, dimension, period string) ([]domain.SalesRow, error) {
+	dimension = strings.TrimPrefix(dimension, "payment.")
+	if !slices.Contains(domain.AnalyticsDimensions, dimension) {
+		return nil, fmt.Errorf("%w: group_by must be one of %s", ErrBadAnalyticsQuery, strings.Join(domain.AnalyticsDimensions, ", "))
+	}
+	if period == "" {
+		period = domain.PeriodDay
+	}
+	if period != domain.PeriodDay && period != domain.PeriodWeek {
+		return nil, fmt.Errorf("%w: period must be day or week", ErrBadAnalyticsQuery)
+	}
+
+	key := fmt.Sprintf("sales/%s/%s/%v", dimension, period, q)
+	return cached(uc, key, func() ([]domain.SalesRow, error) {
+		return uc.repo.Sales(ctx, q, dimension, period)
+	})
+}
+
+// TopSales ranks brands or nm_ids by revenue.
+func (uc *AnalyticsUC) TopSales(ctx context.Context, q domain.AnalyticsQuery, by string, limit int) ([]domain.TopRow, error) {
+	if by != domain.TopByBrand && by != domain.TopByNmID {
+		return nil, fmt.Errorf("%w: by must be brand or nm_id", ErrBadAnalyticsQuery)
+	}
+	if limit <= 0 {
+		limit = DefaultTopLimit
+	}
+	limit = min(limit, MaxTopLimit)
+
+	key := fmt.Sprintf("top/%s/%d/%v", by, limit, q)
+	return cached(uc, key, func() ([]domain.TopRow, error) {
+		return uc.repo.TopSales(ctx, q, by, limit)
+	})
+}
+
+func (uc *AnalyticsUC) Summary(ctx context.Context, q domain.AnalyticsQuery) (domain.SalesSummary, error) {
+	key := fmt.Sprintf("summary/%v", q)
+	return cached(uc, key, func() (domain.SalesSummary, error) {
+		return uc.repo.Summary(ctx, q)
+	})
+}
+
+// Refresh recomputes the views unless another instance is at it.
+func (uc *AnalyticsUC) Refresh(ctx context.Context) error {
+	start := time.Now()
+	ok, err := uc.repo.RefreshAnalytics(ctx)
+	if err != nil {
+		log.Printf("[AnalyticsUC] Refresh failed: %v", err)
+		return err
+	}
+	if !ok {
+		log.Printf("[AnalyticsUC] Refresh skipped, another instance holds the lock")
+		return nil
+	}
+
+	uc.mu.Lock()
+	clear(uc.cache)
+	uc.mu.Unlock()
+	metrics.AnalyticsRefreshDuration.Observe(time.Since(start).Seconds())
+	metrics.AnalyticsLastRefresh.SetToCurrentTime()
+	log.Printf("[AnalyticsUC] Views refreshed in %s", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Run refreshes the views every interval until ctx is done.
+func (uc *AnalyticsUC) Run(ctx context.Context, interval time.Duration) error {
+	log.Printf("analytics views refresh every %s", interval)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		_ = uc.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func cached[T any](uc *AnalyticsUC, key string, load func() (T, error)) (T, error) {
+	uc.mu.Lock()
+	c, ok := uc.cache[key]
+	uc.mu.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		return c.value.(T), nil
+	}
+
+	v, err := load()
+	if err != nil {
+		log.Printf("[AnalyticsUC] DB error: %v", err)
+		return v, err
+	}
+	now := time.Now()
+	uc.mu.Lock()
+	// every date range is a key of its own, drop the stale ones so the map
+	// holds no more than the results of the last TTL
+	for k, c := range uc.cache {
+		if !now.Before(c.expires) {
+			delete(uc.cache, k)
+		}
+	}
+	uc.cache[key] = cachedResult{value: v, expires: now.Add(uc.ttl)}
+	uc.mu.Unlock()
+	return v, nil
+}
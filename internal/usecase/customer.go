@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-service/internal/domain"
+	"time"
+)
+
+var ErrEmptyCustomerID = errors.New("empty customer id")
+
+func (uc *OrderUC) ExportCustomer(ctx context.Context, customerID string) (*domain.CustomerExport, error) {
+	log.Printf("[OrderUC] Exporting data of customer %s", customerID)
+
+	if customerID == "" {
+		return nil, ErrEmptyCustomerID
+	}
+
+	orders, err := uc.repo.FindByCustomer(ctx, customerID)
+	if err != nil {
+		log.Printf("[OrderUC] DB error: %v", err)
+		return nil, err
+	}
+
+	return &domain.CustomerExport{
+		CustomerId: customerID,
+		ExportedAt: time.Now().UTC(),
+		Orders:     orders,
+	}, nil
+}
+
+// EraseCustomer anonymizes delivery PII of the customer in the DB and in the cache.
+// Orders, payments and items stay untouched, accounting still needs them.
+func (uc *OrderUC) EraseCustomer(ctx context.Context, customerID string) (int, error) {
+	log.Printf("[OrderUC] Erasing personal data of customer %s", customerID)
+
+	if customerID == "" {
+		return 0, ErrEmptyCustomerID
+	}
+
+	ids, err := uc.repo.ErasePersonalData(ctx, customerID)
+	if err != nil {
+		log.Printf("[OrderUC] DB error: %v", err)
+		return 0, err
+	}
+
+	for _, id := range ids {
+		cached, ok := uc.cache.Get(id)
+		if !ok {
+			continue
+		}
+		// cached orders may be shared with in-flight readers, replace instead of mutating
+		erased := *cached
+		erased.Delivery.Erase()
+		uc.cache.Set(id, &erased)
+	}
+
+	log.Printf("[OrderUC] Erased personal data of customer %s in %d orders", customerID, len(ids))
+	return len(ids), nil
+}
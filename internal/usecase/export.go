@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"order-service/internal/domain"
+	"strings"
+)
+
+var ErrBadExportFilter = errors.New("bad export filter")
+
+// Export streams the orders matching f to fn, see OrderRepository.ExportOrders.
+func (uc *OrderUC) Export(ctx context.Context, f domain.ExportFilter, fn func(*domain.Order) error) (int, error) {
+	f.Currency = strings.ToUpper(f.Currency)
+	if f.Currency != "" && !validCurrency(f.Currency) {
+		return 0, fmt.Errorf("%w: %q", ErrBadCurrency, f.Currency)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return 0, fmt.Errorf("%w: from is after to", ErrBadExportFilter)
+	}
+	log.Printf("[OrderUC] Exporting orders %+v", f)
+
+	n := 0
+	err := uc.repo.ExportOrders(ctx, f, func(o *domain.Order) error {
+		n++
+		return fn(o)
+	})
+	if err != nil {
+		log.Printf("[OrderUC] Export stopped after %d orders: %v", n, err)
+		return n, err
+	}
+	log.Printf("[OrderUC] Exported %d orders", n)
+	return n, nil
+}
@@ -0,0 +1,417 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"io/fs"
+	"log"
+	"order-service/internal/domain"
+	"order-service/internal/importer"
+	"order-service/internal/metrics"
+	"order-service/internal/repository"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var ErrBadImport = errors.New("bad import")
+
+const (
+	DefaultImportBatch = 500
+	// MaxImportErrors caps the row errors stored per job, later ones are only counted.
+	MaxImportErrors = 10_000
+	// importLease is how long a running job may go without progress before
+	// another worker takes it over, a run renews it a few times per lease.
+	importLease = 2 * time.Minute
+)
+
+// OrderDecoder validates a JSON order, the strict JSON decoder of the consumer.
+type OrderDecoder interface {
+	Decode(ctx context.Context, data []byte) (*domain.Order, error)
+}
+
+// ImportUC loads orders from NDJSON or CSV files in batches. Jobs record
+// the rows done after every batch, an interrupted job goes on from there;
+// a batch repeated after a crash is harmless as stored orders are skipped.
+type ImportUC struct {
+	repo    repository.ImportRepository
+	orders  *OrderUC
+	dec     OrderDecoder
+	batch   int
+	uploads string
+}
+
+// NewImportUC creates the import usecase. Files of jobs in the uploads
+// directory are removed once their job is done, other files belong to
+// whoever started the import; an empty uploads keeps every file.
+func NewImportUC(r repository.ImportRepository, orders *OrderUC, dec OrderDecoder, batch int, uploads string) *ImportUC {
+	if batch <= 0 {
+		batch = DefaultImportBatch
+	}
+	return &ImportUC{repo: r, orders: orders, dec: dec, batch: batch, uploads: uploads}
+}
+
+// Submit queues a job for the workers, see Run.
+func (uc *ImportUC) Submit(ctx context.Context, j *domain.ImportJob) error {
+	if err := uc.check(j); err != nil {
+		return err
+	}
+	j.Status = domain.ImportPending
+	if err := uc.repo.CreateImportJob(ctx, j); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+		return err
+	}
+	log.Printf("[ImportUC] Job %d queued: %s", j.ID, j.Source)
+	return nil
+}
+
+// Start creates a job and runs it in the caller. A job interrupted in the
+// caller fails rather than going back to the queue, so that workers leave
+// it to Resume.
+func (uc *ImportUC) Start(ctx context.Context, j *domain.ImportJob) error {
+	if err := uc.check(j); err != nil {
+		return err
+	}
+	j.Status, j.Claim = domain.ImportRunning, uuid.New()
+	if err := uc.repo.CreateImportJob(ctx, j); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+		return err
+	}
+	return uc.process(ctx, j, false)
+}
+
+// Resume takes over a failed, pending or stalled job and runs it in the
+// caller like Start. It returns nil when the job is unknown, done or still
+// running.
+func (uc *ImportUC) Resume(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	if _, err := uc.repo.RequeueImportJob(ctx, id); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+		return nil, err
+	}
+	j, err := uc.repo.ClaimImportJob(ctx, id, importLease, uuid.New())
+	if err != nil || j == nil {
+		return nil, err
+	}
+	return j, uc.process(ctx, j, false)
+}
+
+// Requeue puts a failed job back in the queue, it reports false for
+// unknown jobs and jobs that did not fail.
+func (uc *ImportUC) Requeue(ctx context.Context, id int64) (bool, error) {
+	return uc.repo.RequeueImportJob(ctx, id)
+}
+
+func (uc *ImportUC) Job(ctx context.Context, id int64) (*domain.ImportJob, error) {
+	return uc.repo.FindImportJob(ctx, id)
+}
+
+func (uc *ImportUC) Jobs(ctx context.Context, limit int) ([]*domain.ImportJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return uc.repo.ListImportJobs(ctx, limit)
+}
+
+// Errors pages through the row errors of a job.
+func (uc *ImportUC) Errors(ctx context.Context, id, afterRow int64, limit int) ([]domain.ImportRowError, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return uc.repo.ImportErrors(ctx, id, afterRow, limit)
+}
+
+// Run works off queued and stalled jobs until ctx is done.
+func (uc *ImportUC) Run(ctx context.Context, interval time.Duration) error {
+	log.Printf("import jobs polled every %s", interval)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			j, err := uc.repo.ClaimImportJob(ctx, 0, importLease, uuid.New())
+			if err != nil {
+				log.Printf("[ImportUC] Claim failed: %v", err)
+				break
+			}
+			if j == nil {
+				break
+			}
+			_ = uc.process(ctx, j, true)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (uc *ImportUC) check(j *domain.ImportJob) error {
+	if j.Format == "" {
+		j.Format = importer.FormatOf(j.Source)
+	}
+	if !slices.Contains(importer.Formats, j.Format) {
+		return fmt.Errorf("%w: %v %q", ErrBadImport, importer.ErrUnknownFormat, j.Format)
+	}
+	if _, err := os.Stat(j.Source); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadImport, err)
+	}
+	return nil
+}
+
+// importRow is a valid order waiting for its batch.
+type importRow struct {
+	row   int64
+	order *domain.Order
+}
+
+// process runs a claimed job. A worker, requeue set, hands the job back to
+// the queue when ctx is done.
+func (uc *ImportUC) process(ctx context.Context, j *domain.ImportJob, requeue bool) error {
+	log.Printf("[ImportUC] Job %d: importing %s from row %d, dry run %t", j.ID, j.Source, j.RowsDone+1, j.DryRun)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go uc.keepLease(ctx, j.ID, j.Claim, cancel)
+
+	f, err := os.Open(j.Source)
+	if err != nil {
+		return uc.fail(ctx, j, err)
+	}
+	defer f.Close()
+
+	rd, err := importer.New(j.Format, f)
+	if err == nil {
+		err = rd.Skip(j.RowsDone)
+	}
+	if err != nil && err != io.EOF {
+		return uc.fail(ctx, j, err)
+	}
+
+	var (
+		batch []importRow
+		errs  []domain.ImportRowError
+		end   = j.RowsDone
+	)
+	for err != io.EOF {
+		var rec domain.ImportRecord
+		if rec, err = rd.Next(); err != nil && err != io.EOF {
+			// a broken file stops here, what was read is still stored
+			err = fmt.Errorf("after row %d: %w", end, err)
+			if ferr := uc.flush(ctx, j, batch, errs, end); ferr != nil {
+				err = ferr
+			}
+			return uc.stop(ctx, j, err, requeue)
+		}
+		if err == nil {
+			if o, rowErr := uc.validate(ctx, rec); rowErr != nil {
+				errs = append(errs, *rowErr)
+			} else {
+				batch = append(batch, importRow{rec.Row, o})
+			}
+			end = rec.End
+		}
+
+		if len(batch)+len(errs) < uc.batch && err == nil {
+			continue
+		}
+		if ferr := uc.flush(ctx, j, batch, errs, end); ferr != nil {
+			return uc.stop(ctx, j, ferr, requeue)
+		}
+		batch, errs = batch[:0], errs[:0]
+		if ctx.Err() != nil {
+			return uc.stop(ctx, j, ctx.Err(), requeue)
+		}
+	}
+
+	now := time.Now()
+	j.Status, j.FinishedAt = domain.ImportDone, &now
+	if err := uc.repo.SaveImportProgress(ctx, j, nil); errors.Is(err, domain.ErrImportLost) {
+		return uc.stop(ctx, j, err, requeue)
+	} else if err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+		return err
+	}
+	log.Printf("[ImportUC] Job %d done: %d rows, %d orders ok, %d already stored, %d rejected", j.ID, j.RowsDone, j.OrdersOK, j.OrdersSkipped, j.OrdersBad)
+	uc.removeUpload(j)
+	return nil
+}
+
+// removeUpload deletes the uploaded file of a done job, a failed job keeps
+// it to be resumed.
+func (uc *ImportUC) removeUpload(j *domain.ImportJob) {
+	if uc.uploads == "" {
+		return
+	}
+	dir, err := filepath.Abs(uc.uploads)
+	if err != nil {
+		return
+	}
+	src, err := filepath.Abs(j.Source)
+	if err != nil {
+		return
+	}
+	if rel, err := filepath.Rel(dir, src); err != nil || !filepath.IsLocal(rel) {
+		return
+	}
+	if err := os.Remove(j.Source); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("[ImportUC] Job %d: cannot remove upload: %v", j.ID, err)
+	}
+}
+
+// validate decodes the order like a strict Kafka message. Item statuses are
+// numeric codes in the DB, batches would fail on anything else.
+func (uc *ImportUC) validate(ctx context.Context, rec domain.ImportRecord) (*domain.Order, *domain.ImportRowError) {
+	rowErr := func(err error) *domain.ImportRowError {
+		metrics.ImportOrders.WithLabelValues("invalid").Inc()
+		var probe struct {
+			OrderUID string `json:"order_uid"`
+		}
+		_ = json.Unmarshal(rec.Data, &probe)
+		return &domain.ImportRowError{Row: rec.Row, OrderUID: probe.OrderUID, Error: err.Error()}
+	}
+
+	if rec.Err != nil {
+		return nil, rowErr(rec.Err)
+	}
+	o, err := uc.dec.Decode(ctx, rec.Data)
+	if err != nil {
+		return nil, rowErr(err)
+	}
+	for i, it := range o.Items {
+		if _, err := strconv.Atoi(it.Status); err != nil {
+			return nil, rowErr(fmt.Errorf("item %d: status %q is not a number", i, it.Status))
+		}
+	}
+	return o, nil
+}
+
+// flush stores a batch and the progress up to row end. An order failing in
+// the DB is retried alone and reported as a row error, the batch fails when
+// they all do, as that is rather the DB than the orders. Orders stored
+// before are counted as skipped.
+func (uc *ImportUC) flush(ctx context.Context, j *domain.ImportJob, batch []importRow, errs []domain.ImportRowError, end int64) error {
+	ok, skipped := len(batch), 0
+	if !j.DryRun && len(batch) > 0 {
+		orders := make([]*domain.Order, len(batch))
+		for i, r := range batch {
+			orders[i] = r.order
+		}
+		n, err := uc.orders.SetNewBatch(ctx, orders)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			log.Printf("[ImportUC] Job %d: batch failed, storing its orders one by one: %v", j.ID, err)
+			var failed []domain.ImportRowError
+			n = 0
+			for _, r := range batch {
+				one, oneErr := uc.orders.SetNewBatch(ctx, []*domain.Order{r.order})
+				if oneErr != nil {
+					failed = append(failed, domain.ImportRowError{Row: r.row, OrderUID: r.order.OrderId.String(), Error: oneErr.Error()})
+				}
+				n += one
+			}
+			if len(failed) == len(batch) && len(batch) > 1 {
+				return err
+			}
+			metrics.ImportOrders.WithLabelValues("failed").Add(float64(len(failed)))
+			errs = append(errs, failed...)
+			skipped = len(batch) - len(failed) - n
+		} else {
+			skipped = len(batch) - n
+		}
+		ok = n
+	}
+	metrics.ImportOrders.WithLabelValues("ok").Add(float64(ok))
+	metrics.ImportOrders.WithLabelValues("skipped").Add(float64(skipped))
+
+	next := *j
+	next.RowsDone = end
+	next.OrdersOK += int64(ok)
+	next.OrdersSkipped += int64(skipped)
+	next.OrdersBad += int64(len(errs))
+	// row errors past the cap are counted, not stored
+	if room := MaxImportErrors - j.OrdersBad; room < int64(len(errs)) {
+		errs = errs[:max(room, 0)]
+	}
+	if err := uc.repo.SaveImportProgress(ctx, &next, errs); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+		return err
+	}
+	*j = next
+	return nil
+}
+
+// keepLease renews the lease of a running job until ctx is done, a batch
+// may take longer than the lease. It cancels the run when the job was
+// taken over all the same.
+func (uc *ImportUC) keepLease(ctx context.Context, id int64, claim uuid.UUID, cancel context.CancelCauseFunc) {
+	t := time.NewTicker(importLease / 4)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		ok, err := uc.repo.RenewImportLease(ctx, id, claim)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[ImportUC] Job %d: lease not renewed: %v", id, err)
+			}
+			continue
+		}
+		if !ok {
+			cancel(domain.ErrImportLost)
+			return
+		}
+	}
+}
+
+// stop ends a run that did not get through the file. A job taken over by
+// another worker is left to it, a cancelled run is interrupted and any
+// other cause fails the job.
+func (uc *ImportUC) stop(ctx context.Context, j *domain.ImportJob, cause error, requeue bool) error {
+	switch {
+	case errors.Is(cause, domain.ErrImportLost) || errors.Is(context.Cause(ctx), domain.ErrImportLost):
+		log.Printf("[ImportUC] Job %d taken over by another worker after row %d, stopping", j.ID, j.RowsDone)
+		return domain.ErrImportLost
+	case ctx.Err() != nil:
+		return uc.interrupt(j, requeue)
+	}
+	return uc.fail(ctx, j, cause)
+}
+
+// fail stops the job, it can be requeued once the cause is fixed.
+func (uc *ImportUC) fail(ctx context.Context, j *domain.ImportJob, cause error) error {
+	log.Printf("[ImportUC] Job %d failed: %v", j.ID, cause)
+	now := time.Now()
+	j.Status, j.LastError, j.FinishedAt = domain.ImportFailed, cause.Error(), &now
+	if err := uc.repo.SaveImportProgress(context.WithoutCancel(ctx), j, nil); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+	}
+	return cause
+}
+
+// interrupt stops the job on shutdown. A worker hands it back to the
+// queue, the next worker goes on from the rows done; a job run in the
+// caller fails, it goes on with Resume.
+func (uc *ImportUC) interrupt(j *domain.ImportJob, requeue bool) error {
+	log.Printf("[ImportUC] Job %d interrupted after row %d", j.ID, j.RowsDone)
+	j.Status = domain.ImportPending
+	if !requeue {
+		now := time.Now()
+		j.Status, j.LastError, j.FinishedAt = domain.ImportFailed, fmt.Sprintf("interrupted after row %d", j.RowsDone), &now
+	}
+	if err := uc.repo.SaveImportProgress(context.Background(), j, nil); err != nil {
+		log.Printf("[ImportUC] DB error: %v", err)
+	}
+	return context.Canceled
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"order-service/internal/domain"
+)
+
+const (
+	DefaultListLimit = 10
+	MaxListLimit     = 100
+)
+
+// List returns the latest orders of the customer, or of all customers when
+// customerID is empty, newest first.
+func (uc *OrderUC) List(ctx context.Context, customerID string, limit int) ([]*domain.Order, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	log.Printf("[OrderUC] Listing orders, customer=%q limit=%d", customerID, limit)
+
+	if customerID == "" {
+		return uc.repo.FindRecent(ctx, limit)
+	}
+
+	orders, err := uc.repo.FindRecentByCustomer(ctx, customerID, limit)
+	if err != nil {
+		log.Printf("[OrderUC] DB error: %v", err)
+		return nil, err
+	}
+	return orders, nil
+}
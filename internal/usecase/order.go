@@ -9,12 +9,13 @@ import (
 )
 
 type OrderUC struct {
-	repo  repository.OrderRepository
-	cache Cache
+	repo   repository.OrderRepository
+	cache  Cache
+	events Publisher
 }
 
-func NewOrederUC(r repository.OrderRepository, c Cache) *OrderUC {
-	return &OrderUC{r, c}
+func NewOrederUC(r repository.OrderRepository, c Cache, p Publisher) *OrderUC {
+	return &OrderUC{r, c, p}
 }
 
 func (uc *OrderUC) Get(ctx context.Context, id string) (*domain.Order, error) {
@@ -63,5 +64,40 @@ func (uc *OrderUC) Set(ctx context.Context, order *domain.Order) error {
 	log.Printf("[OrderUC] Successfully saved to DB: %s", orderID)
 	uc.cache.Set(orderID, order)
 	log.Printf("[OrderUC] Successfully saved to cache: %s", orderID)
+	uc.events.Publish(EventOrderSaved, order)
 	return nil
 }
+
+func (uc *OrderUC) SetBatch(ctx context.Context, orders []*domain.Order) error {
+	_, err := uc.SetNewBatch(ctx, orders)
+	return err
+}
+
+// SetNewBatch stores the orders and returns how many of them were new.
+// Orders already stored are skipped by the DB, they are neither cached nor
+// published again.
+func (uc *OrderUC) SetNewBatch(ctx context.Context, orders []*domain.Order) (int, error) {
+	log.Printf("[OrderUC] Saving batch of %d orders", len(orders))
+
+	inserted, err := uc.repo.SaveBatch(ctx, orders)
+	if err != nil {
+		log.Printf("[OrderUC] Error saving batch to DB: %v", err)
+		return 0, err
+	}
+
+	isNew := make(map[uuid.UUID]bool, len(inserted))
+	for _, id := range inserted {
+		isNew[id] = true
+	}
+	for _, order := range orders {
+		// a repeated order was inserted once, as its first copy
+		if !isNew[order.OrderId] {
+			continue
+		}
+		delete(isNew, order.OrderId)
+		uc.cache.Set(order.OrderId.String(), order)
+		uc.events.Publish(EventOrderSaved, order)
+	}
+	log.Printf("[OrderUC] Successfully saved batch of %d orders, %d new", len(orders), len(inserted))
+	return len(inserted), nil
+}
@@ -0,0 +1,13 @@
+package usecase
+
+import "order-service/internal/domain"
+
+const (
+	EventOrderSaved   = "order.saved"
+	EventOrderUpdated = "order.updated"
+)
+
+// Publisher receives order events after they are persisted.
+type Publisher interface {
+	Publish(eventType string, order *domain.Order)
+}
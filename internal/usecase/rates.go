@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"order-service/internal/domain"
+	"order-service/internal/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBadCurrency = errors.New("currency must be a 3-letter code")
+	ErrBadRate     = errors.New("rate must be positive")
+	ErrNoRate      = errors.New("no exchange rate")
+)
+
+// rateCacheTTL bounds how long a rate loaded by another process stays unseen.
+const rateCacheTTL = 10 * time.Minute
+
+type rateKey struct {
+	currency string
+	day      time.Time
+}
+
+type cachedRate struct {
+	rate    *domain.ExchangeRate
+	fetched time.Time
+}
+
+// RatesUC values amounts in other currencies through the exchange_rates
+// table, whose rates are quoted in the base currency. A currency is valued
+// at its latest rate on or before the date.
+type RatesUC struct {
+	repo repository.RateRepository
+	base string
+
+	mu    sync.Mutex
+	cache map[rateKey]cachedRate
+}
+
+func NewRatesUC(r repository.RateRepository, base string) *RatesUC {
+	return &RatesUC{repo: r, base: strings.ToUpper(base), cache: make(map[rateKey]cachedRate)}
+}
+
+func (uc *RatesUC) Base() string {
+	return uc.base
+}
+
+// Load validates and stores rates, the base currency itself is rejected as
+// it is always 1.
+func (uc *RatesUC) Load(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	for i := range rates {
+		r := &rates[i]
+		r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+		if !validCurrency(r.Currency) {
+			return 0, fmt.Errorf("%w: %q", ErrBadCurrency, r.Currency)
+		}
+		if r.Currency == uc.base {
+			return 0, fmt.Errorf("%s is the base currency, its rate is always 1", r.Currency)
+		}
+		if r.Rate == nil || r.Rate.Sign() <= 0 {
+			return 0, fmt.Errorf("%w: %s on %s", ErrBadRate, r.Currency, r.Date.Format(time.DateOnly))
+		}
+		r.Date = day(r.Date)
+	}
+
+	log.Printf("[RatesUC] Loading %d exchange rates", len(rates))
+	n, err := uc.repo.SaveRates(ctx, rates)
+	if err != nil {
+		log.Printf("[RatesUC] DB error: %v", err)
+		return 0, err
+	}
+
+	uc.mu.Lock()
+	clear(uc.cache)
+	uc.mu.Unlock()
+	return n, nil
+}
+
+// Convert values m in currency at the rates of the date.
+func (uc *RatesUC) Convert(ctx context.Context, m domain.Money, currency string, on time.Time) (domain.Money, error) {
+	currency = strings.ToUpper(currency)
+	if m.Currency() == currency {
+		return m, nil
+	}
+
+	from, _, err := uc.rate(ctx, m.Currency(), on)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	to, _, err := uc.rate(ctx, currency, on)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	return m.Convert(new(big.Rat).Quo(from, to), currency)
+}
+
+// Value values the payment of the order in currency, the base currency when
+// empty, at the rates of the day the order was created.
+func (uc *RatesUC) Value(ctx context.Context, o *domain.Order, currency string) (*domain.Valuation, error) {
+	if currency == "" {
+		currency = uc.base
+	}
+	currency = strings.ToUpper(currency)
+	if !validCurrency(currency) {
+		return nil, fmt.Errorf("%w: %q", ErrBadCurrency, currency)
+	}
+
+	on := o.DateCreated
+	from, fromDate, err := uc.rate(ctx, o.Payment.Currency, on)
+	if err != nil {
+		return nil, err
+	}
+	to, toDate, err := uc.rate(ctx, currency, on)
+	if err != nil {
+		return nil, err
+	}
+	rate := new(big.Rat).Quo(from, to)
+
+	v := &domain.Valuation{Currency: currency, RateDate: day(on)}
+	// the oldest of the rates used, it tells how stale the valuation is
+	for _, d := range []time.Time{fromDate, toDate} {
+		if !d.IsZero() && d.Before(v.RateDate) {
+			v.RateDate = d
+		}
+	}
+	for _, f := range []struct {
+		dst *domain.Money
+		src domain.Money
+	}{
+		{&v.Amount, o.Payment.Amount},
+		{&v.DeliveryCost, o.Payment.DeliveryCost},
+		{&v.GoodsTotal, o.Payment.GoodsTotal},
+		{&v.CustomFee, o.Payment.CustomFee},
+	} {
+		if *f.dst, err = f.src.Convert(rate, currency); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// rate returns units of the base currency per unit of currency and the date
+// of the rate, zero for the base currency.
+func (uc *RatesUC) rate(ctx context.Context, currency string, on time.Time) (*big.Rat, time.Time, error) {
+	currency = strings.ToUpper(currency)
+	if currency == uc.base {
+		return big.NewRat(1, 1), time.Time{}, nil
+	}
+
+	key := rateKey{currency, day(on)}
+	uc.mu.Lock()
+	c, ok := uc.cache[key]
+	uc.mu.Unlock()
+	if !ok || time.Since(c.fetched) > rateCacheTTL {
+		r, err := uc.repo.FindRate(ctx, currency, key.day)
+		if err != nil {
+			log.Printf("[RatesUC] DB error: %v", err)
+			return nil, time.Time{}, err
+		}
+		c = cachedRate{rate: r, fetched: time.Now()}
+		uc.mu.Lock()
+		uc.cache[key] = c
+		uc.mu.Unlock()
+	}
+
+	if c.rate == nil {
+		return nil, time.Time{}, fmt.Errorf("%w for %s on %s", ErrNoRate, currency, key.day.Format(time.DateOnly))
+	}
+	return c.rate.Rate, c.rate.Date, nil
+}
+
+func validCurrency(c string) bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"order-service/internal/domain"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// FieldChange is one difference between the stored order and a replayed one.
+type FieldChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+type ReplayResult struct {
+	OrderId string        `json:"order_uid"`
+	Created bool          `json:"created"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// Replay re-ingests an order idempotently: unchanged orders are left alone,
+// changed ones are overwritten. With dryRun nothing is written and the
+// result only reports what would change. The delivery of an erased order
+// is erased in order first, it is neither shown nor a change.
+func (uc *OrderUC) Replay(ctx context.Context, order *domain.Order, dryRun bool) (*ReplayResult, error) {
+	id := order.OrderId.String()
+	res := &ReplayResult{OrderId: id}
+
+	stored, err := uc.repo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	erased, err := uc.repo.PersonalDataErased(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if erased {
+		order.Delivery.Erase()
+	}
+	if stored == nil {
+		res.Created = true
+	} else if res.Changes, err = diffOrders(stored, order); err != nil {
+		return nil, err
+	}
+
+	if dryRun || (!res.Created && len(res.Changes) == 0) {
+		return res, nil
+	}
+
+	log.Printf("[OrderUC] Replaying order %s, created=%t changes=%d", id, res.Created, len(res.Changes))
+	if _, err = uc.repo.Upsert(ctx, order); err != nil {
+		log.Printf("[OrderUC] Error upserting to DB: %v", err)
+		return nil, err
+	}
+	uc.cache.Set(id, order)
+	if res.Created {
+		uc.events.Publish(EventOrderSaved, order)
+	} else {
+		uc.events.Publish(EventOrderUpdated, order)
+	}
+	return res, nil
+}
+
+// diffOrders compares orders by their JSON form, so the paths match the wire format.
+func diffOrders(old, new *domain.Order) ([]FieldChange, error) {
+	a, err := flatten(normalized(old))
+	if err != nil {
+		return nil, err
+	}
+	b, err := flatten(normalized(new))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]FieldChange, 0)
+	for path, v := range b {
+		if ov, ok := a[path]; !ok || !reflect.DeepEqual(ov, v) {
+			changes = append(changes, FieldChange{Path: path, Old: a[path], New: v})
+		}
+	}
+	for path, v := range a {
+		if _, ok := b[path]; !ok {
+			changes = append(changes, FieldChange{Path: path, Old: v})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// normalized drops what Postgres does not keep: time zone and sub-microsecond precision.
+func normalized(o *domain.Order) *domain.Order {
+	cp := *o
+	cp.DateCreated = o.DateCreated.UTC().Truncate(time.Microsecond)
+	// status comes from the event topics, replay never overwrites it
+	cp.Status = ""
+	return &cp
+}
+
+func flatten(o *domain.Order) (map[string]any, error) {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	var tree any
+	if err = json.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any)
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, child := range t {
+				if prefix == "" {
+					walk(k, child)
+				} else {
+					walk(prefix+"."+k, child)
+				}
+			}
+		case []any:
+			for i, child := range t {
+				walk(fmt.Sprintf("%s[%d]", prefix, i), child)
+			}
+		default:
+			out[prefix] = v
+		}
+	}
+	walk("", tree)
+	return out, nil
+}
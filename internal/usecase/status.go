@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"order-service/internal/domain"
+)
+
+// ErrOrderNotFound is returned by updates of orders not stored yet, the
+// consumer parks them so they can be replayed once the order arrives.
+var ErrOrderNotFound = errors.New("order not found")
+
+// ConfirmPayment stores the settled payment of an order.
+func (uc *OrderUC) ConfirmPayment(ctx context.Context, c domain.PaymentConfirmation) error {
+	id := c.OrderUID.String()
+	log.Printf("[OrderUC] Confirming payment %s of order %s", c.TransactionId, id)
+
+	ok, err := uc.repo.ConfirmPayment(ctx, c)
+	if err != nil {
+		log.Printf("[OrderUC] DB error: %v", err)
+		return err
+	}
+	if !ok {
+		log.Printf("[OrderUC] Payment for unknown order %s", id)
+		return ErrOrderNotFound
+	}
+	return uc.refresh(ctx, id)
+}
+
+// UpdateDeliveryStatus sets the status reported by the delivery service.
+func (uc *OrderUC) UpdateDeliveryStatus(ctx context.Context, u domain.StatusUpdate) error {
+	return uc.updateStatus(ctx, u)
+}
+
+// Cancel marks the order cancelled.
+func (uc *OrderUC) Cancel(ctx context.Context, c domain.Cancellation) error {
+	return uc.updateStatus(ctx, domain.StatusUpdate{
+		OrderUID:  c.OrderUID,
+		Status:    domain.StatusCancelled,
+		Reason:    c.Reason,
+		UpdatedAt: c.CancelledAt,
+	})
+}
+
+func (uc *OrderUC) updateStatus(ctx context.Context, u domain.StatusUpdate) error {
+	id := u.OrderUID.String()
+	log.Printf("[OrderUC] Setting status of order %s to %s", id, u.Status)
+
+	ok, err := uc.repo.UpdateStatus(ctx, u)
+	if err != nil {
+		log.Printf("[OrderUC] DB error: %v", err)
+		return err
+	}
+	if ok {
+		return uc.refresh(ctx, id)
+	}
+
+	stored, err := uc.repo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		log.Printf("[OrderUC] Status for unknown order %s", id)
+		return ErrOrderNotFound
+	}
+	log.Printf("[OrderUC] Stale status %s of order %s at %s ignored", u.Status, id, u.UpdatedAt)
+	return nil
+}
+
+// refresh reloads an updated order into the cache and notifies subscribers.
+func (uc *OrderUC) refresh(ctx context.Context, id string) error {
+	order, err := uc.repo.Find(ctx, id)
+	if err != nil || order == nil {
+		return err
+	}
+	uc.cache.Set(id, order)
+	uc.events.Publish(EventOrderUpdated, order)
+	return nil
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"order-service/internal/domain"
+	"order-service/internal/repository"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http(s) url")
+	ErrUnknownEventType  = errors.New("unknown event type")
+)
+
+type WebhookUC struct {
+	repo repository.WebhookRepository
+}
+
+func NewWebhookUC(r repository.WebhookRepository) *WebhookUC {
+	return &WebhookUC{r}
+}
+
+// Subscribe registers a webhook endpoint. A signing secret is generated
+// when none is given, it is returned only here. Event types must be ones of
+// domain.EventTypes, none subscribes to all.
+func (uc *WebhookUC) Subscribe(ctx context.Context, s *domain.WebhookSubscription) error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	for _, t := range s.EventTypes {
+		if !slices.Contains(domain.EventTypes, t) {
+			return fmt.Errorf("%w %q, must be one of %s", ErrUnknownEventType, t, strings.Join(domain.EventTypes, ", "))
+		}
+	}
+
+	if s.Secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		s.Secret = hex.EncodeToString(buf)
+	}
+	s.Active = true
+
+	if err := uc.repo.CreateSubscription(ctx, s); err != nil {
+		log.Printf("[WebhookUC] DB error: %v", err)
+		return err
+	}
+	log.Printf("[WebhookUC] Subscribed %d -> %s", s.ID, s.URL)
+	return nil
+}
+
+func (uc *WebhookUC) Subscriptions(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	return uc.repo.ListSubscriptions(ctx)
+}
+
+func (uc *WebhookUC) Unsubscribe(ctx context.Context, id int64) (bool, error) {
+	log.Printf("[WebhookUC] Unsubscribing %d", id)
+	return uc.repo.DeleteSubscription(ctx, id)
+}
+
+func (uc *WebhookUC) Deliveries(ctx context.Context, subscriptionID int64, limit int) ([]*domain.WebhookDelivery, error) {
+	if limit <= 0 || limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+	return uc.repo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+func (uc *WebhookUC) Replay(ctx context.Context, deliveryID int64) (bool, error) {
+	log.Printf("[WebhookUC] Replaying delivery %d", deliveryID)
+	return uc.repo.ReplayDelivery(ctx, deliveryID)
+}